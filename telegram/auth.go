@@ -1 +1,241 @@
 package gotele
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TelegramIPRanges are the published subnets Telegram delivers webhook requests from
+var TelegramIPRanges = []string{
+	"149.154.160.0/20",
+	"91.108.4.0/22",
+}
+
+// ClientIPResolver resolves the client IP of a request. Forwarding headers are only
+// honoured when the direct peer is one of the trusted proxies
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+// NewClientIPResolver creates a resolver that trusts forwarding headers from the given proxy CIDRs
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	nets, err := parseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+	return &ClientIPResolver{trustedProxies: nets}, nil
+}
+
+// ClientIP returns the IP address of the client that originated the request
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if c == nil || !c.isTrusted(remote) {
+		return remote
+	}
+
+	// Walk X-Forwarded-For from the nearest hop backwards and stop at the first
+	// address that is not one of our own proxies
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if i == 0 || !c.isTrusted(hop) {
+				return hop
+			}
+		}
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(xri) != nil {
+		return xri
+	}
+
+	return remote
+}
+
+func (c *ClientIPResolver) isTrusted(ip string) bool {
+	return containsIP(c.trustedProxies, ip)
+}
+
+// IPAllowlist restricts webhook requests to a set of source networks
+type IPAllowlist struct {
+	allowed  []*net.IPNet
+	resolver *ClientIPResolver
+}
+
+// NewIPAllowlist creates an allowlist for the given CIDRs, defaulting to TelegramIPRanges.
+// The client IP is resolved through the given trusted proxy CIDRs
+func NewIPAllowlist(allowedCIDRs, trustedProxies []string) (*IPAllowlist, error) {
+	if len(allowedCIDRs) == 0 {
+		allowedCIDRs = TelegramIPRanges
+	}
+
+	allowed, err := parseCIDRs(allowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed network: %w", err)
+	}
+
+	resolver, err := NewClientIPResolver(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPAllowlist{allowed: allowed, resolver: resolver}, nil
+}
+
+// Allows reports whether ip is inside one of the allowed networks
+func (a *IPAllowlist) Allows(ip string) bool {
+	return containsIP(a.allowed, ip)
+}
+
+// Middleware rejects requests from clients outside the allowlist. The client IP is resolved
+// with the allowlist's trusted proxies; without any, the IP a ClientIPResolver middleware
+// resolved earlier in the chain is used
+func (a *IPAllowlist) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := getClientIP(r)
+		if len(a.resolver.trustedProxies) > 0 {
+			ip = a.resolver.ClientIP(r)
+			r = withClientIP(r, ip)
+		}
+
+		if !a.Allows(ip) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Middleware resolves the client IP once and stores it in the request context, where the
+// allowlist, rate limiting, WebhookUpdate.IPAddress and WebhookLogger pick it up
+func (c *ClientIPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, withClientIP(r, c.ClientIP(r)))
+	})
+}
+
+type clientIPKey struct{}
+
+// withClientIP returns a request whose context carries the resolved client IP
+func withClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+}
+
+// getClientIP returns the client IP resolved earlier in the chain, falling back to the direct peer address
+func getClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the IP address of the direct peer
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range nets {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipRateLimiter is a token bucket rate limiter keyed by client IP
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newIPRateLimiter(rate float64, burst int) *ipRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &ipRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow reports whether a request from ip may proceed, consuming a token if so
+func (l *ipRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute
+func (l *ipRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for ip, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, ip)
+		}
+	}
+}
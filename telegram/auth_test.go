@@ -0,0 +1,158 @@
+package gotele
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		realIP     string
+		expected   string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.7:443", "149.154.160.1", "", "203.0.113.7"},
+		{"trusted peer uses forwarded address", "10.0.0.2:443", "149.154.160.1", "", "149.154.160.1"},
+		{"spoofed left-most entry is skipped", "10.0.0.2:443", "1.2.3.4, 149.154.160.1", "", "149.154.160.1"},
+		{"trusted hops are skipped", "10.0.0.2:443", "149.154.160.1, 10.0.0.5", "", "149.154.160.1"},
+		{"trusted peer uses X-Real-IP", "10.0.0.2:443", "", "91.108.4.10", "91.108.4.10"},
+		{"invalid header falls back to peer", "10.0.0.2:443", "garbage", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/webhook", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+
+		if ip := resolver.ClientIP(req); ip != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, ip)
+		}
+	}
+
+	if _, err := NewClientIPResolver([]string{"not-a-cidr"}); err == nil {
+		t.Error("Expected error for invalid CIDR")
+	}
+}
+
+func TestIPAllowlist(t *testing.T) {
+	allowlist, err := NewIPAllowlist(nil, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !allowlist.Allows("149.154.167.200") || !allowlist.Allows("91.108.6.1") {
+		t.Error("Expected Telegram ranges to be allowed by default")
+	}
+	if allowlist.Allows("8.8.8.8") {
+		t.Error("Expected address outside Telegram ranges to be rejected")
+	}
+
+	var seenIP string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenIP = getClientIP(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	resolver, _ := NewClientIPResolver([]string{"10.0.0.0/8"})
+	handler := WebhookLoggerWithResolver(resolver, allowlist.Middleware(next))
+
+	req := httptest.NewRequest("POST", "/webhook", nil)
+	req.RemoteAddr = "10.0.0.2:443"
+	req.Header.Set("X-Forwarded-For", "149.154.167.200")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if seenIP != "149.154.167.200" {
+		t.Errorf("Expected handler to see resolved IP, got %s", seenIP)
+	}
+
+	// A direct caller cannot spoof its way in
+	req = httptest.NewRequest("POST", "/webhook", nil)
+	req.RemoteAddr = "203.0.113.7:443"
+	req.Header.Set("X-Forwarded-For", "149.154.167.200")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestIPRateLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := newIPRateLimiter(1, 2)
+	limiter.now = func() time.Time { return now }
+
+	if !limiter.allow("1.1.1.1") || !limiter.allow("1.1.1.1") {
+		t.Fatal("Expected burst requests to be allowed")
+	}
+	if limiter.allow("1.1.1.1") {
+		t.Error("Expected request beyond burst to be limited")
+	}
+	if !limiter.allow("2.2.2.2") {
+		t.Error("Expected other IPs to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if !limiter.allow("1.1.1.1") {
+		t.Error("Expected a token to be refilled after one second")
+	}
+
+	now = now.Add(2 * time.Minute)
+	limiter.allow("3.3.3.3")
+	if _, ok := limiter.buckets["1.1.1.1"]; ok {
+		t.Error("Expected idle buckets to be swept")
+	}
+}
+
+func TestClientIPResolverWithoutAllowlist(t *testing.T) {
+	bot := NewBot("test_token")
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var metadata *WebhookUpdate
+	handler := bot.webhookServerHandler(&WebhookServer{
+		ClientIPResolver: resolver,
+		UpdateHandler: func(ctx context.Context, u *Update) error {
+			metadata, _ = WebhookUpdateFromContext(ctx)
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1}`))
+	req.RemoteAddr = "10.0.0.2:443"
+	req.Header.Set("X-Forwarded-For", "198.51.100.4")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if metadata == nil || metadata.IPAddress != "198.51.100.4" {
+		t.Errorf("Expected IPAddress to be the resolved client IP, got %+v", metadata)
+	}
+
+	// The allowlist uses the IP resolved by the server when it has no trusted proxies of its own
+	allowlist, _ := NewIPAllowlist([]string{"198.51.100.0/24"}, nil)
+	w := httptest.NewRecorder()
+	resolver.Middleware(allowlist.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the resolved IP to be allowed, got %d", w.Code)
+	}
+}
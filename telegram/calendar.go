@@ -0,0 +1,307 @@
+package gotele
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Weekday and month names used by calendars unless configured otherwise
+var (
+	DefaultWeekdayNames = [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}
+	DefaultMonthNames   = [12]string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+)
+
+// CalendarOptions configures a Calendar
+type CalendarOptions struct {
+	ID           string                                // Identifies the calendar in callbacks, letters and digits
+	Min, Max     time.Time                             // Selectable range of days, zero for no bound
+	Disabled     func(day time.Time) bool              // Reports days that cannot be selected, optional
+	WeekStart    time.Weekday                          // First column of the grid
+	Location     *time.Location                        // Defaults to UTC
+	WeekdayNames [7]string                             // Indexed by time.Weekday, defaults to DefaultWeekdayNames
+	MonthNames   [12]string                            // Defaults to DefaultMonthNames
+	OnSelect     func(c *Context, day time.Time) error // Called with midnight of the selected day
+}
+
+// Calendar is an inline keyboard for picking a day. Month navigation edits the keyboard in place
+type Calendar struct {
+	options CalendarOptions
+}
+
+// NewCalendar creates a calendar. ID and OnSelect are required
+func NewCalendar(options *CalendarOptions) (*Calendar, error) {
+	if options == nil {
+		return nil, errors.New("calendar options are required")
+	}
+
+	cal := &Calendar{options: *options}
+	if err := validWidgetID(cal.options.ID); err != nil {
+		return nil, err
+	}
+	if cal.options.OnSelect == nil {
+		return nil, errors.New("calendar needs an OnSelect handler")
+	}
+	if cal.options.Location == nil {
+		cal.options.Location = time.UTC
+	}
+	if cal.options.WeekdayNames == ([7]string{}) {
+		cal.options.WeekdayNames = DefaultWeekdayNames
+	}
+	if cal.options.MonthNames == ([12]string{}) {
+		cal.options.MonthNames = DefaultMonthNames
+	}
+	return cal, nil
+}
+
+// Keyboard renders the month containing month
+func (cal *Calendar) Keyboard(month time.Time) (*InlineKeyboardMarkup, error) {
+	month = month.In(cal.options.Location)
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, cal.options.Location)
+	last := first.AddDate(0, 1, -1)
+	noop := cal.data("x")
+
+	kb := NewInlineKeyboard()
+	if cal.options.Min.IsZero() || cal.options.Min.Before(first) {
+		kb.Callback("‹", cal.data("m"+first.AddDate(0, -1, 0).Format("200601")))
+	} else {
+		kb.Callback(" ", noop)
+	}
+	kb.Callback(fmt.Sprintf("%s %d", cal.options.MonthNames[first.Month()-1], first.Year()), noop)
+	if next := first.AddDate(0, 1, 0); cal.options.Max.IsZero() || !cal.options.Max.Before(next) {
+		kb.Callback("›", cal.data("m"+next.Format("200601")))
+	} else {
+		kb.Callback(" ", noop)
+	}
+
+	kb.Row()
+	for i := 0; i < 7; i++ {
+		kb.Callback(cal.options.WeekdayNames[(int(cal.options.WeekStart)+i)%7], noop)
+	}
+
+	kb.Row().Columns(7)
+	for blank := (int(first.Weekday()) - int(cal.options.WeekStart) + 7) % 7; blank > 0; blank-- {
+		kb.Callback(" ", noop)
+	}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if cal.selectable(day) {
+			kb.Callback(strconv.Itoa(day.Day()), cal.data("d"+day.Format("20060102")))
+		} else {
+			kb.Callback("·", noop)
+		}
+	}
+	for trailing := (7 - (int(last.Weekday())-int(cal.options.WeekStart)+7)%7 - 1); trailing > 0; trailing-- {
+		kb.Callback(" ", noop)
+	}
+	return kb.Build()
+}
+
+// Register handles the calendar's callbacks in the group
+func (cal *Calendar) Register(g *Group) {
+	prefix := cal.data("")
+	g.CallbackQuery(HandleContext(cal.handle), func(u *Update) bool {
+		return strings.HasPrefix(u.CallbackQuery.Data, prefix)
+	})
+}
+
+func (cal *Calendar) handle(c *Context) error {
+	data := strings.TrimPrefix(c.Update.CallbackQuery.Data, cal.data(""))
+	if data == "" {
+		return nil
+	}
+
+	switch data[0] {
+	case 'm':
+		month, err := time.ParseInLocation("200601", data[1:], cal.options.Location)
+		if err != nil {
+			return nil
+		}
+		markup, err := cal.Keyboard(month)
+		if err != nil {
+			return err
+		}
+		return c.EditReplyMarkup(markup)
+	case 'd':
+		day, err := time.ParseInLocation("20060102", data[1:], cal.options.Location)
+		if err != nil || !cal.selectable(day) {
+			// Forged or outdated data
+			return nil
+		}
+		return cal.options.OnSelect(c, day)
+	}
+	return nil
+}
+
+// selectable reports whether day, at midnight, lies within the bounds and is not disabled
+func (cal *Calendar) selectable(day time.Time) bool {
+	if !cal.options.Min.IsZero() {
+		min := cal.options.Min.In(cal.options.Location)
+		if day.Before(time.Date(min.Year(), min.Month(), min.Day(), 0, 0, 0, 0, cal.options.Location)) {
+			return false
+		}
+	}
+	if !cal.options.Max.IsZero() && day.After(cal.options.Max) {
+		return false
+	}
+	return cal.options.Disabled == nil || !cal.options.Disabled(day)
+}
+
+func (cal *Calendar) data(rest string) string {
+	return "cal:" + cal.options.ID + ":" + rest
+}
+
+// DefaultTimePickerStep is the interval between times offered by a TimePicker
+const DefaultTimePickerStep = 15 * time.Minute
+
+// TimePickerOptions configures a TimePicker
+type TimePickerOptions struct {
+	ID       string         // Identifies the picker in callbacks, letters and digits
+	Step     time.Duration  // Interval between offered times, defaults to DefaultTimePickerStep
+	Earliest time.Duration  // Earliest time of day offered, as an offset from midnight
+	Latest   time.Duration  // Latest time of day offered, defaults to the end of the day
+	Columns  int            // Buttons per row, defaults to 4
+	Location *time.Location // Time zone of the offered times, defaults to UTC
+	OnSelect func(c *Context, t time.Time) error
+}
+
+// TimePicker is an inline keyboard for picking a time on a given day. With steps shorter than an
+// hour the user picks the hour first and then the minutes, so keyboards stay small
+type TimePicker struct {
+	options TimePickerOptions
+}
+
+// NewTimePicker creates a time picker. ID and OnSelect are required
+func NewTimePicker(options *TimePickerOptions) (*TimePicker, error) {
+	if options == nil {
+		return nil, errors.New("time picker options are required")
+	}
+
+	tp := &TimePicker{options: *options}
+	if err := validWidgetID(tp.options.ID); err != nil {
+		return nil, err
+	}
+	if tp.options.OnSelect == nil {
+		return nil, errors.New("time picker needs an OnSelect handler")
+	}
+	if tp.options.Step <= 0 {
+		tp.options.Step = DefaultTimePickerStep
+	}
+	if tp.options.Latest <= 0 || tp.options.Latest >= 24*time.Hour {
+		tp.options.Latest = 24*time.Hour - time.Minute
+	}
+	if tp.options.Columns <= 0 {
+		tp.options.Columns = 4
+	}
+	if tp.options.Location == nil {
+		tp.options.Location = time.UTC
+	}
+	return tp, nil
+}
+
+// Keyboard renders the times offered on the day of date
+func (tp *TimePicker) Keyboard(date time.Time) (*InlineKeyboardMarkup, error) {
+	date = date.In(tp.options.Location)
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, tp.options.Location)
+	kb := NewInlineKeyboard().Columns(tp.options.Columns)
+
+	if tp.options.Step >= time.Hour {
+		for offset := tp.options.Earliest; offset <= tp.options.Latest; offset += tp.options.Step {
+			t := midnight.Add(offset)
+			kb.Callback(t.Format("15:04"), tp.data("t"+t.Format("200601021504")))
+		}
+		return kb.Build()
+	}
+
+	for hour := tp.options.Earliest.Truncate(time.Hour); hour <= tp.options.Latest; hour += time.Hour {
+		t := midnight.Add(hour)
+		kb.Callback(t.Format("15:00"), tp.data("h"+t.Format("2006010215")))
+	}
+	return kb.Build()
+}
+
+// minutesKeyboard renders the times offered within an hour
+func (tp *TimePicker) minutesKeyboard(hour time.Time) (*InlineKeyboardMarkup, error) {
+	kb := NewInlineKeyboard().Columns(tp.options.Columns)
+	for minute := time.Duration(0); minute < time.Hour; minute += tp.options.Step {
+		t := hour.Add(minute)
+		if !tp.offered(t) {
+			continue
+		}
+		kb.Callback(t.Format("15:04"), tp.data("t"+t.Format("200601021504")))
+	}
+	kb.Row().Columns(0).Callback("‹ Back", tp.data("b"+hour.Format("20060102")))
+	return kb.Build()
+}
+
+// Register handles the time picker's callbacks in the group
+func (tp *TimePicker) Register(g *Group) {
+	prefix := tp.data("")
+	g.CallbackQuery(HandleContext(tp.handle), func(u *Update) bool {
+		return strings.HasPrefix(u.CallbackQuery.Data, prefix)
+	})
+}
+
+func (tp *TimePicker) handle(c *Context) error {
+	data := strings.TrimPrefix(c.Update.CallbackQuery.Data, tp.data(""))
+	if data == "" {
+		return nil
+	}
+	location := tp.options.Location
+
+	switch data[0] {
+	case 'h':
+		hour, err := time.ParseInLocation("2006010215", data[1:], location)
+		if err != nil {
+			return nil
+		}
+		markup, err := tp.minutesKeyboard(hour)
+		if err != nil {
+			return err
+		}
+		return c.EditReplyMarkup(markup)
+	case 'b':
+		day, err := time.ParseInLocation("20060102", data[1:], location)
+		if err != nil {
+			return nil
+		}
+		markup, err := tp.Keyboard(day)
+		if err != nil {
+			return err
+		}
+		return c.EditReplyMarkup(markup)
+	case 't':
+		t, err := time.ParseInLocation("200601021504", data[1:], location)
+		if err != nil || !tp.offered(t) {
+			return nil
+		}
+		return tp.options.OnSelect(c, t)
+	}
+	return nil
+}
+
+// offered reports whether t is a time the picker offers
+func (tp *TimePicker) offered(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if offset < tp.options.Earliest || offset > tp.options.Latest {
+		return false
+	}
+	if tp.options.Step >= time.Hour {
+		return (offset-tp.options.Earliest)%tp.options.Step == 0
+	}
+	return time.Duration(t.Minute())*time.Minute%tp.options.Step == 0
+}
+
+func (tp *TimePicker) data(rest string) string {
+	return "tp:" + tp.options.ID + ":" + rest
+}
+
+// validWidgetID checks the ID of a keyboard widget used in callback data
+func validWidgetID(id string) error {
+	if id == "" || strings.ContainsAny(id, ": ") {
+		return fmt.Errorf("invalid widget ID %q", id)
+	}
+	return nil
+}
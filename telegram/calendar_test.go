@@ -0,0 +1,132 @@
+package gotele
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCalendarKeyboard(t *testing.T) {
+	cal, err := NewCalendar(&CalendarOptions{
+		ID:        "book",
+		WeekStart: time.Monday,
+		Min:       time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		Max:       time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Disabled:  func(day time.Time) bool { return day.Weekday() == time.Sunday },
+		OnSelect:  func(c *Context, day time.Time) error { return nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	keyboard, err := cal.Keyboard(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rows := keyboard.InlineKeyboard
+
+	if got := buttonTexts(rows[0]); got[0] != " " || got[1] != "March 2026" || got[2] != " " {
+		t.Errorf("Expected navigation disabled at both bounds, got %q", got)
+	}
+	if rows[1][0].Text != "Mo" || rows[1][6].Text != "Su" {
+		t.Errorf("Expected the week to start on Monday, got %q", buttonTexts(rows[1]))
+	}
+	// March 1st 2026 is a Sunday, so the first week has six leading blanks
+	if rows[2][5].Text != " " || rows[2][6].Text != "·" {
+		t.Errorf("Unexpected first week %q", buttonTexts(rows[2]))
+	}
+	if rows[3][0].Text != "·" || rows[4][1].Text != "10" || rows[4][1].CallbackData != "cal:book:d20260310" {
+		t.Errorf("Expected days before Min to be disabled, got %q and %+v", buttonTexts(rows[3]), rows[4][1])
+	}
+	if rows[4][5].Text != "14" || rows[4][6].Text != "·" {
+		t.Errorf("Expected Sundays to be disabled, got %q", buttonTexts(rows[4]))
+	}
+	for _, row := range rows[2:] {
+		if len(row) != 7 {
+			t.Errorf("Expected full weeks, got %q", buttonTexts(row))
+		}
+	}
+}
+
+func TestCalendarCallbacks(t *testing.T) {
+	bot, api := newTestBot(t)
+	var selected time.Time
+	cal, _ := NewCalendar(&CalendarOptions{
+		ID:       "book",
+		Max:      time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		OnSelect: func(c *Context, day time.Time) error { selected = day; return nil },
+	})
+	router := NewRouter()
+	cal.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	_ = router.HandleUpdate(ctx, menuCallback("cal:book:m202604"))
+	rows := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if rows[0][1].Text != "April 2026" {
+		t.Errorf("Expected navigation to April, got %q", buttonTexts(rows[0]))
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("cal:book:d20270105"))
+	if !selected.IsZero() {
+		t.Errorf("Expected a day after Max to be ignored, got %v", selected)
+	}
+	_ = router.HandleUpdate(ctx, menuCallback("cal:book:d20260405"))
+	if !selected.Equal(time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected April 5th, got %v", selected)
+	}
+}
+
+func TestTimePicker(t *testing.T) {
+	bot, api := newTestBot(t)
+	var selected time.Time
+	picker, err := NewTimePicker(&TimePickerOptions{
+		ID:       "at",
+		Step:     20 * time.Minute,
+		Earliest: 9 * time.Hour,
+		Latest:   17 * time.Hour,
+		OnSelect: func(c *Context, at time.Time) error { selected = at; return nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	keyboard, _ := picker.Keyboard(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC))
+	hours := keyboard.InlineKeyboard
+	if hours[0][0].Text != "09:00" || hours[0][0].CallbackData != "tp:at:h2026050109" || hours[2][0].Text != "17:00" {
+		t.Errorf("Unexpected hour grid %v", hours)
+	}
+
+	router := NewRouter()
+	picker.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	_ = router.HandleUpdate(ctx, menuCallback("tp:at:h2026050110"))
+	minutes := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if got := buttonTexts(minutes[0]); len(got) != 3 || got[2] != "10:40" || minutes[1][0].Text != "‹ Back" {
+		t.Errorf("Unexpected minute grid %v", minutes)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("tp:at:t202605011015"))
+	if !selected.IsZero() {
+		t.Errorf("Expected a time off the step to be ignored, got %v", selected)
+	}
+	_ = router.HandleUpdate(ctx, menuCallback("tp:at:t202605011040"))
+	if !selected.Equal(time.Date(2026, 5, 1, 10, 40, 0, 0, time.UTC)) {
+		t.Errorf("Expected 10:40, got %v", selected)
+	}
+
+	hourly, _ := NewTimePicker(&TimePickerOptions{ID: "h", Step: 2 * time.Hour, OnSelect: picker.options.OnSelect})
+	keyboard, _ = hourly.Keyboard(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC))
+	if keyboard.InlineKeyboard[0][1].Text != "02:00" || keyboard.InlineKeyboard[0][1].CallbackData != "tp:h:t202605010200" {
+		t.Errorf("Expected direct time buttons for long steps, got %v", keyboard.InlineKeyboard[0])
+	}
+}
+
+func TestCalendarNilOptions(t *testing.T) {
+	if _, err := NewCalendar(nil); err == nil {
+		t.Error("Expected an error for a calendar without options")
+	}
+	if _, err := NewTimePicker(nil); err == nil {
+		t.Error("Expected an error for a time picker without options")
+	}
+}
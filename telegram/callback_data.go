@@ -0,0 +1,379 @@
+package gotele
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// MaxCallbackDataBytes is the largest callback data Telegram accepts
+const MaxCallbackDataBytes = 64
+
+// DefaultCallbackMACSize is the number of HMAC bytes kept in signed callback data
+const DefaultCallbackMACSize = 8
+
+const (
+	callbackInline byte = iota // The payload follows
+	callbackStored             // A CallbackStore key follows
+)
+
+// CallbackCodecOptions configures a CallbackCodec
+type CallbackCodecOptions struct {
+	MACSize int           // HMAC bytes kept, 4-32. Defaults to DefaultCallbackMACSize
+	Store   CallbackStore // Holds payloads that do not fit into 64 bytes, optional
+}
+
+// CallbackCodec encodes Go structs into signed callback data of the form "prefix.signed-payload".
+// Fields are written in order as varints, length-prefixed strings and bytes, so the result is far
+// smaller than JSON. A truncated HMAC-SHA256 detects data forged by modified clients
+type CallbackCodec struct {
+	secret  []byte
+	options CallbackCodecOptions
+
+	prefixes map[reflect.Type]string
+	types    map[string]reflect.Type
+}
+
+// NewCallbackCodec creates a codec signing data with secret. Options may be nil
+func NewCallbackCodec(secret []byte, options *CallbackCodecOptions) *CallbackCodec {
+	c := &CallbackCodec{
+		secret:   secret,
+		prefixes: map[reflect.Type]string{},
+		types:    map[string]reflect.Type{},
+	}
+	if options != nil {
+		c.options = *options
+	}
+	if c.options.MACSize <= 0 {
+		c.options.MACSize = DefaultCallbackMACSize
+	}
+	if c.options.MACSize < 4 {
+		c.options.MACSize = 4
+	}
+	if c.options.MACSize > sha256.Size {
+		c.options.MACSize = sha256.Size
+	}
+	return c
+}
+
+// Register assigns a type prefix to the struct type of v. The prefix identifies the type in
+// callback data, so it must stay stable while buttons using it exist. It may contain letters,
+// digits, '_' and '-'. Fields may be integers, floats, bools, strings, slices, arrays and structs
+func (c *CallbackCodec) Register(prefix string, v interface{}) error {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("callback data for prefix %q must be a struct, got %T", prefix, v)
+	}
+	if prefix == "" || strings.IndexFunc(prefix, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) >= 0 {
+		return fmt.Errorf("invalid callback prefix %q", prefix)
+	}
+	if other, ok := c.types[prefix]; ok && other != t {
+		return fmt.Errorf("callback prefix %q is already used by %s", prefix, other)
+	}
+	if err := checkCallbackType(t); err != nil {
+		return err
+	}
+	c.prefixes[t] = prefix
+	c.types[prefix] = t
+	return nil
+}
+
+// Encode encodes v, whose type must be registered, into callback data
+func (c *CallbackCodec) Encode(v interface{}) (string, error) {
+	return c.EncodeWithContext(context.Background(), v)
+}
+
+// EncodeWithContext encodes v into callback data with context support. Data that would exceed
+// 64 bytes is saved in the CallbackStore, or rejected with ErrCallbackDataTooLong without one
+func (c *CallbackCodec) EncodeWithContext(ctx context.Context, v interface{}) (string, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if !value.IsValid() {
+		return "", fmt.Errorf("cannot encode %T as callback data", v)
+	}
+	prefix, ok := c.prefixes[value.Type()]
+	if !ok {
+		return "", fmt.Errorf("callback data type %s is not registered", value.Type())
+	}
+
+	payload, err := appendCallbackValue(nil, value)
+	if err != nil {
+		return "", err
+	}
+
+	data := c.sign(prefix, append([]byte{callbackInline}, payload...))
+	if len(data) <= MaxCallbackDataBytes {
+		return data, nil
+	}
+	if c.options.Store == nil {
+		return "", fmt.Errorf("%w: %s needs %d bytes", ErrCallbackDataTooLong, prefix, len(data))
+	}
+
+	key := make([]byte, 9)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate callback key: %w", err)
+	}
+	encodedKey := base64.RawURLEncoding.EncodeToString(key)
+	if err := c.options.Store.SaveCallback(ctx, encodedKey, payload); err != nil {
+		return "", fmt.Errorf("failed to store callback data: %w", err)
+	}
+
+	data = c.sign(prefix, append([]byte{callbackStored}, key...))
+	if len(data) > MaxCallbackDataBytes {
+		return "", fmt.Errorf("%w: prefix %q is too long", ErrCallbackDataTooLong, prefix)
+	}
+	return data, nil
+}
+
+// Decode decodes callback data into v, a pointer to a registered type
+func (c *CallbackCodec) Decode(data string, v interface{}) error {
+	return c.DecodeWithContext(context.Background(), data, v)
+}
+
+// DecodeWithContext decodes callback data into v with context support. Data with another
+// prefix, a bad signature or an expired stored payload fails with ErrInvalidCallbackData
+func (c *CallbackCodec) DecodeWithContext(ctx context.Context, data string, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("callback data must be decoded into a pointer, got %T", v)
+	}
+	prefix, ok := c.prefixes[target.Elem().Type()]
+	if !ok {
+		return fmt.Errorf("callback data type %s is not registered", target.Elem().Type())
+	}
+
+	body, ok := c.verify(prefix, data)
+	if !ok || len(body) == 0 {
+		return ErrInvalidCallbackData
+	}
+
+	payload := body[1:]
+	switch body[0] {
+	case callbackInline:
+	case callbackStored:
+		if c.options.Store == nil {
+			return ErrInvalidCallbackData
+		}
+		stored, err := c.options.Store.LoadCallback(ctx, base64.RawURLEncoding.EncodeToString(payload))
+		if err != nil {
+			return fmt.Errorf("failed to load callback data: %w", err)
+		}
+		if stored == nil {
+			return fmt.Errorf("%w: stored payload expired", ErrInvalidCallbackData)
+		}
+		payload = stored
+	default:
+		return ErrInvalidCallbackData
+	}
+
+	reader := &callbackReader{data: payload}
+	if err := reader.readValue(target.Elem()); err != nil || len(reader.data) > 0 {
+		return ErrInvalidCallbackData
+	}
+	return nil
+}
+
+// sign returns prefix, a dot and the base64 encoded body followed by its truncated HMAC
+func (c *CallbackCodec) sign(prefix string, body []byte) string {
+	signed := append(body, c.mac(prefix, body)...)
+	return prefix + "." + base64.RawURLEncoding.EncodeToString(signed)
+}
+
+// verify checks the prefix and signature of data and returns its body
+func (c *CallbackCodec) verify(prefix, data string) ([]byte, bool) {
+	encoded, ok := strings.CutPrefix(data, prefix+".")
+	if !ok {
+		return nil, false
+	}
+	signed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(signed) < c.options.MACSize {
+		return nil, false
+	}
+	body, mac := signed[:len(signed)-c.options.MACSize], signed[len(signed)-c.options.MACSize:]
+	if !hmac.Equal(mac, c.mac(prefix, body)) {
+		return nil, false
+	}
+	return body, true
+}
+
+func (c *CallbackCodec) mac(prefix string, body []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(prefix))
+	h.Write([]byte{0})
+	h.Write(body)
+	return h.Sum(nil)[:c.options.MACSize]
+}
+
+type callbackDataKey struct{}
+
+// Callback registers a handler for callback queries carrying data of type T encoded by codec.
+// Callback queries with invalid or forged data do not match. T must be registered with codec
+func Callback[T any](g *Group, codec *CallbackCodec, handler func(ctx context.Context, u *Update, data T) error, filters ...Filter) {
+	g.handleMatch(UpdateTypeCallbackQuery, func(ctx context.Context, u *Update) (context.Context, bool) {
+		var data T
+		if err := codec.DecodeWithContext(ctx, u.CallbackQuery.Data, &data); err != nil {
+			return ctx, false
+		}
+		return context.WithValue(ctx, callbackDataKey{}, data), true
+	}, func(ctx context.Context, u *Update) error {
+		return handler(ctx, u, ctx.Value(callbackDataKey{}).(T))
+	}, filters)
+}
+
+// checkCallbackType reports an error if values of t cannot be encoded
+func checkCallbackType(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nil
+	case reflect.Slice, reflect.Array:
+		return checkCallbackType(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if err := checkCallbackType(field.Type); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("callback data cannot hold %s values", t)
+	}
+}
+
+// appendCallbackValue appends the compact encoding of v
+func appendCallbackValue(buf []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binary.AppendUvarint(buf, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.Float())), nil
+	case reflect.String:
+		buf = binary.AppendUvarint(buf, uint64(v.Len()))
+		return append(buf, v.String()...), nil
+	case reflect.Slice:
+		buf = binary.AppendUvarint(buf, uint64(v.Len()))
+		fallthrough
+	case reflect.Array:
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if buf, err = appendCallbackValue(buf, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case reflect.Struct:
+		var err error
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if buf, err = appendCallbackValue(buf, v.Field(i)); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("callback data cannot hold %s values", v.Type())
+	}
+}
+
+// callbackReader decodes values written by appendCallbackValue
+type callbackReader struct {
+	data []byte
+}
+
+func (r *callbackReader) readValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if len(r.data) == 0 || r.data[0] > 1 {
+			return ErrInvalidCallbackData
+		}
+		v.SetBool(r.data[0] == 1)
+		r.data = r.data[1:]
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, size := binary.Varint(r.data)
+		if size <= 0 || v.OverflowInt(n) {
+			return ErrInvalidCallbackData
+		}
+		v.SetInt(n)
+		r.data = r.data[size:]
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := r.readUvarint()
+		if err != nil || v.OverflowUint(n) {
+			return ErrInvalidCallbackData
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if len(r.data) < 8 {
+			return ErrInvalidCallbackData
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(r.data)))
+		r.data = r.data[8:]
+	case reflect.String:
+		n, err := r.readUvarint()
+		if err != nil || n > uint64(len(r.data)) {
+			return ErrInvalidCallbackData
+		}
+		v.SetString(string(r.data[:n]))
+		r.data = r.data[n:]
+	case reflect.Slice:
+		n, err := r.readUvarint()
+		if err != nil || n > uint64(len(r.data)) {
+			// Every element takes at least one byte
+			return ErrInvalidCallbackData
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n), int(n)))
+		for i := 0; i < int(n); i++ {
+			if err := r.readValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.readValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := r.readValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrInvalidCallbackData
+	}
+	return nil
+}
+
+func (r *callbackReader) readUvarint() (uint64, error) {
+	n, size := binary.Uvarint(r.data)
+	if size <= 0 {
+		return 0, ErrInvalidCallbackData
+	}
+	r.data = r.data[size:]
+	return n, nil
+}
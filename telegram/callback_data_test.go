@@ -0,0 +1,137 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pageCallback struct {
+	Page   int
+	Query  string
+	Sort   bool
+	Filter []uint8
+}
+
+func newTestCodec(t *testing.T, store CallbackStore) *CallbackCodec {
+	t.Helper()
+	codec := NewCallbackCodec([]byte("secret"), &CallbackCodecOptions{Store: store})
+	if err := codec.Register("pg", pageCallback{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return codec
+}
+
+func TestCallbackCodecRoundTrip(t *testing.T) {
+	codec := newTestCodec(t, nil)
+	in := pageCallback{Page: -3, Query: "go", Sort: true, Filter: []uint8{1, 2}}
+
+	data, err := codec.Encode(in)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(data, "pg.") || len(data) > MaxCallbackDataBytes {
+		t.Errorf("Unexpected callback data %q", data)
+	}
+
+	var out pageCallback
+	if err := codec.Decode(data, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if out.Page != in.Page || out.Query != in.Query || !out.Sort || len(out.Filter) != 2 || out.Filter[1] != 2 {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+}
+
+func TestCallbackCodecRejectsTampering(t *testing.T) {
+	codec := newTestCodec(t, nil)
+	data, _ := codec.Encode(pageCallback{Page: 1})
+
+	forged := []byte(data)
+	if forged[4] == 'A' {
+		forged[4] = 'B'
+	} else {
+		forged[4] = 'A'
+	}
+
+	var out pageCallback
+	for _, candidate := range []string{string(forged), "pg.", "other.AAAA", "pg.!!!"} {
+		if err := codec.Decode(candidate, &out); !errors.Is(err, ErrInvalidCallbackData) {
+			t.Errorf("Decode(%q): expected ErrInvalidCallbackData, got %v", candidate, err)
+		}
+	}
+
+	other := NewCallbackCodec([]byte("other secret"), nil)
+	_ = other.Register("pg", pageCallback{})
+	if err := other.Decode(data, &out); !errors.Is(err, ErrInvalidCallbackData) {
+		t.Errorf("Expected data signed with another secret to be rejected, got %v", err)
+	}
+}
+
+func TestCallbackCodecTooLong(t *testing.T) {
+	codec := newTestCodec(t, nil)
+	large := pageCallback{Query: strings.Repeat("x", 60)}
+
+	if _, err := codec.Encode(large); !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("Expected ErrCallbackDataTooLong, got %v", err)
+	}
+
+	codec = newTestCodec(t, NewMemoryCallbackStore(time.Hour))
+	data, err := codec.Encode(large)
+	if err != nil {
+		t.Fatalf("Expected fallback to the store, got %v", err)
+	}
+	if len(data) > MaxCallbackDataBytes {
+		t.Errorf("Expected stored reference within 64 bytes, got %d", len(data))
+	}
+
+	var out pageCallback
+	if err := codec.Decode(data, &out); err != nil || out.Query != large.Query {
+		t.Errorf("Expected stored payload, got %+v, %v", out, err)
+	}
+}
+
+func TestCallbackCodecRegister(t *testing.T) {
+	codec := NewCallbackCodec([]byte("secret"), nil)
+	if err := codec.Register("bad prefix", pageCallback{}); err == nil {
+		t.Error("Expected invalid prefix to be rejected")
+	}
+	if err := codec.Register("m", map[string]int{}); err == nil {
+		t.Error("Expected non-struct type to be rejected")
+	}
+	if err := codec.Register("p", struct{ Next *int }{}); err == nil {
+		t.Error("Expected pointer field to be rejected")
+	}
+	if _, err := codec.Encode(pageCallback{}); err == nil {
+		t.Error("Expected unregistered type to be rejected")
+	}
+}
+
+func TestRouterCallback(t *testing.T) {
+	codec := newTestCodec(t, nil)
+	data, _ := codec.Encode(pageCallback{Page: 4})
+
+	var got pageCallback
+	var unmatched int
+	router := NewRouter()
+	Callback(router.Group, codec, func(ctx context.Context, u *Update, page pageCallback) error {
+		got = page
+		return nil
+	})
+	router.NotFound(func(ctx context.Context, u *Update) error {
+		unmatched++
+		return nil
+	})
+
+	_ = router.HandleUpdate(context.Background(), &Update{CallbackQuery: &CallbackQuery{Data: data}})
+	_ = router.HandleUpdate(context.Background(), &Update{CallbackQuery: &CallbackQuery{Data: "pg.forged"}})
+
+	if got.Page != 4 {
+		t.Errorf("Expected page 4, got %+v", got)
+	}
+	if unmatched != 1 {
+		t.Errorf("Expected forged data not to match, got %d unmatched", unmatched)
+	}
+}
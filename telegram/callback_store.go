@@ -0,0 +1,66 @@
+package gotele
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallbackStore keeps callback payloads too large for the 64-byte callback data limit.
+// Buttons then carry only a short key referring to the stored payload
+type CallbackStore interface {
+	// SaveCallback stores data under key
+	SaveCallback(ctx context.Context, key string, data []byte) error
+	// LoadCallback returns the data stored under key, or nil if there is none
+	LoadCallback(ctx context.Context, key string) ([]byte, error)
+}
+
+// MemoryCallbackStore keeps callback payloads in memory
+type MemoryCallbackStore struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	payloads map[string]storedCallback
+}
+
+type storedCallback struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCallbackStore creates an in-memory callback store. Payloads are dropped ttl after
+// they were saved, so buttons older than that stop working; 0 keeps them forever
+func NewMemoryCallbackStore(ttl time.Duration) *MemoryCallbackStore {
+	return &MemoryCallbackStore{ttl: ttl, payloads: map[string]storedCallback{}}
+}
+
+// SaveCallback implements CallbackStore
+func (s *MemoryCallbackStore) SaveCallback(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stored := storedCallback{data: append([]byte(nil), data...)}
+	if s.ttl > 0 {
+		stored.expiresAt = now.Add(s.ttl)
+		for k, payload := range s.payloads {
+			if now.After(payload.expiresAt) {
+				delete(s.payloads, k)
+			}
+		}
+	}
+	s.payloads[key] = stored
+	return nil
+}
+
+// LoadCallback implements CallbackStore
+func (s *MemoryCallbackStore) LoadCallback(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.payloads[key]
+	if !ok || (!stored.expiresAt.IsZero() && time.Now().After(stored.expiresAt)) {
+		return nil, nil
+	}
+	return stored.data, nil
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -92,6 +94,54 @@ func (b *Bot) makeRequest(ctx context.Context, method, endpoint string, body int
 	return &apiResp, nil
 }
 
+// GetMe returns basic information about the bot
+func (b *Bot) GetMe() (*User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	return b.GetMeWithContext(ctx)
+}
+
+// GetMeWithContext returns basic information about the bot with context support.
+// The result is cached for Username
+func (b *Bot) GetMeWithContext(ctx context.Context) (*User, error) {
+	resp, err := b.makeRequest(ctx, "GET", "/getMe", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the result into User
+	var me User
+	resultBytes, err := json.Marshal(resp.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if err := json.Unmarshal(resultBytes, &me); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+
+	b.meMu.Lock()
+	b.me = &me
+	b.meMu.Unlock()
+	return &me, nil
+}
+
+// Username returns the bot's username, calling getMe only the first time
+func (b *Bot) Username(ctx context.Context) (string, error) {
+	b.meMu.Lock()
+	me := b.me
+	b.meMu.Unlock()
+	if me != nil {
+		return me.Username, nil
+	}
+
+	me, err := b.GetMeWithContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return me.Username, nil
+}
+
 type sendMessageRequest struct {
 	ChatID                   int64           `json:"chat_id"`
 	MessageThreadID          int             `json:"message_thread_id,omitempty"`
@@ -120,7 +170,7 @@ func (b *Bot) SendMessageWithContext(ctx context.Context, chatID int64, text str
 		Text:   text,
 	}
 
-	_, err := b.makeRequest(ctx, "POST", "/sendMessage", reqBody)
+	_, err := b.sendMessage(ctx, reqBody)
 	return err
 }
 
@@ -146,6 +196,33 @@ func (b *Bot) SendMessageAdvanced(chatID int64, text string, options *SendMessag
 
 // SendMessageAdvancedWithContext sends a message with advanced options and context support
 func (b *Bot) SendMessageAdvancedWithContext(ctx context.Context, chatID int64, text string, options *SendMessageOptions) error {
+	_, err := b.sendMessage(ctx, newSendMessageRequest(chatID, text, options))
+	return err
+}
+
+// sendMessage calls sendMessage and returns the sent message
+func (b *Bot) sendMessage(ctx context.Context, reqBody sendMessageRequest) (*Message, error) {
+	resp, err := b.makeRequest(ctx, "POST", "/sendMessage", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the result into Message
+	var message Message
+	resultBytes, err := json.Marshal(resp.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if err := json.Unmarshal(resultBytes, &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	return &message, nil
+}
+
+// newSendMessageRequest builds the sendMessage parameters from the given options
+func newSendMessageRequest(chatID int64, text string, options *SendMessageOptions) sendMessageRequest {
 	reqBody := sendMessageRequest{
 		ChatID: chatID,
 		Text:   text,
@@ -163,22 +240,61 @@ func (b *Bot) SendMessageAdvancedWithContext(ctx context.Context, chatID int64,
 		reqBody.ReplyMarkup = options.ReplyMarkup
 	}
 
-	_, err := b.makeRequest(ctx, "POST", "/sendMessage", reqBody)
-	return err
+	return reqBody
 }
 
+// DefaultPollTimeout is the long polling timeout used by GetUpdates
+const DefaultPollTimeout = 30 * time.Second
+
 // GetUpdates fetches new messages from Telegram using long polling
 func (b *Bot) GetUpdates(offset int) ([]Update, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	// The HTTP deadline has to outlast the long polling timeout
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout+DefaultPollTimeout)
 	defer cancel()
 	return b.GetUpdatesWithContext(ctx, offset)
 }
 
 // GetUpdatesWithContext fetches new messages from Telegram using long polling with context support
 func (b *Bot) GetUpdatesWithContext(ctx context.Context, offset int) ([]Update, error) {
-	endpoint := fmt.Sprintf("/getUpdates?timeout=30&offset=%d", offset)
+	return b.GetUpdatesAdvancedWithContext(ctx, &GetUpdatesOptions{
+		Offset:  offset,
+		Timeout: DefaultPollTimeout,
+	})
+}
+
+// GetUpdatesOptions represents options for fetching updates
+type GetUpdatesOptions struct {
+	Offset         int
+	Limit          int           // 1-100, Telegram defaults to 100
+	Timeout        time.Duration // Long polling timeout, 0 for short polling
+	AllowedUpdates []string
+}
+
+// GetUpdatesAdvanced fetches updates with advanced options
+func (b *Bot) GetUpdatesAdvanced(options *GetUpdatesOptions) ([]Update, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout+options.Timeout)
+	defer cancel()
+	return b.GetUpdatesAdvancedWithContext(ctx, options)
+}
+
+// GetUpdatesAdvancedWithContext fetches updates with advanced options and context support.
+// The context deadline should leave room for the long polling timeout
+func (b *Bot) GetUpdatesAdvancedWithContext(ctx context.Context, options *GetUpdatesOptions) ([]Update, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(options.Offset))
+	query.Set("timeout", strconv.Itoa(int(options.Timeout/time.Second)))
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if options.AllowedUpdates != nil {
+		allowedUpdatesJSON, err := json.Marshal(options.AllowedUpdates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal allowed updates: %w", err)
+		}
+		query.Set("allowed_updates", string(allowedUpdatesJSON))
+	}
 
-	resp, err := b.makeRequest(ctx, "GET", endpoint, nil)
+	resp, err := b.makeRequest(ctx, "GET", "/getUpdates?"+query.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -272,6 +388,52 @@ func (b *Bot) DeleteMessageWithContext(ctx context.Context, chatID int64, messag
 	return err
 }
 
+// SetMyCommands sets the bot's default command list
+func (b *Bot) SetMyCommands(commands []BotCommand) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	return b.SetMyCommandsWithContext(ctx, commands)
+}
+
+// SetMyCommandsWithContext sets the bot's default command list with context support
+func (b *Bot) SetMyCommandsWithContext(ctx context.Context, commands []BotCommand) error {
+	return b.SetMyCommandsAdvancedWithContext(ctx, commands, nil)
+}
+
+// SetMyCommandsOptions represents options for setting the command list
+type SetMyCommandsOptions struct {
+	Scope        *BotCommandScope
+	LanguageCode string // Two-letter ISO 639-1 code, empty for users without a dedicated list
+}
+
+// SetMyCommandsAdvanced sets the command list for a scope and language
+func (b *Bot) SetMyCommandsAdvanced(commands []BotCommand, options *SetMyCommandsOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	return b.SetMyCommandsAdvancedWithContext(ctx, commands, options)
+}
+
+// SetMyCommandsAdvancedWithContext sets the command list for a scope and language with context support
+func (b *Bot) SetMyCommandsAdvancedWithContext(ctx context.Context, commands []BotCommand, options *SetMyCommandsOptions) error {
+	if commands == nil {
+		commands = []BotCommand{}
+	}
+	reqBody := map[string]interface{}{
+		"commands": commands,
+	}
+	if options != nil {
+		if options.Scope != nil {
+			reqBody["scope"] = options.Scope
+		}
+		if options.LanguageCode != "" {
+			reqBody["language_code"] = options.LanguageCode
+		}
+	}
+
+	_, err := b.makeRequest(ctx, "POST", "/setMyCommands", reqBody)
+	return err
+}
+
 // SendPhotoOptions represents options for sending a photo
 type SendPhotoOptions struct {
 	ChatID                   int64
@@ -342,6 +504,12 @@ func (b *Bot) AnswerCallbackQuery(options *AnswerCallbackQueryOptions) error {
 
 // AnswerCallbackQueryWithContext answers a callback query with context support
 func (b *Bot) AnswerCallbackQueryWithContext(ctx context.Context, options *AnswerCallbackQueryOptions) error {
+	_, err := b.makeRequest(ctx, "POST", "/answerCallbackQuery", answerCallbackQueryParams(options))
+	return err
+}
+
+// answerCallbackQueryParams builds the answerCallbackQuery parameters from the given options
+func answerCallbackQueryParams(options *AnswerCallbackQueryOptions) map[string]interface{} {
 	reqBody := map[string]interface{}{
 		"callback_query_id": options.CallbackQueryID,
 	}
@@ -359,8 +527,7 @@ func (b *Bot) AnswerCallbackQueryWithContext(ctx context.Context, options *Answe
 		reqBody["cache_time"] = options.CacheTime
 	}
 
-	_, err := b.makeRequest(ctx, "POST", "/answerCallbackQuery", reqBody)
-	return err
+	return reqBody
 }
 
 // AnswerInlineQuery answers an inline query
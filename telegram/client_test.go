@@ -0,0 +1,146 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// apiCall records a single request made against the fake Bot API
+type apiCall struct {
+	Method string
+	Params map[string]interface{}
+}
+
+// fakeAPI is a minimal Bot API server for tests. Responses are keyed by method name
+type fakeAPI struct {
+	mu        sync.Mutex
+	calls     []apiCall
+	responses map[string]func(params map[string]interface{}) (interface{}, *APIResponse)
+}
+
+// newTestBot returns a bot that talks to a fake Bot API server
+func newTestBot(t *testing.T) (*Bot, *fakeAPI) {
+	t.Helper()
+
+	api := &fakeAPI{responses: map[string]func(map[string]interface{}) (interface{}, *APIResponse){}}
+	server := httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(server.Close)
+
+	bot := NewBot("test_token")
+	bot.BaseURL = server.URL
+	return bot, api
+}
+
+// on sets the result returned for a method
+func (a *fakeAPI) on(method string, result interface{}) {
+	a.handle(method, func(map[string]interface{}) (interface{}, *APIResponse) { return result, nil })
+}
+
+// handle sets a dynamic response for a method. A non-nil APIResponse is returned as an error response
+func (a *fakeAPI) handle(method string, fn func(params map[string]interface{}) (interface{}, *APIResponse)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.responses[method] = fn
+}
+
+// methods returns the names of the methods called so far
+func (a *fakeAPI) methods() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	names := make([]string, len(a.calls))
+	for i, call := range a.calls {
+		names[i] = call.Method
+	}
+	return names
+}
+
+// callsTo returns the recorded calls to a method
+func (a *fakeAPI) callsTo(method string) []apiCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var calls []apiCall
+	for _, call := range a.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (a *fakeAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	params := map[string]interface{}{}
+	for key, values := range r.URL.Query() {
+		params[key] = values[0]
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			for key, values := range r.MultipartForm.Value {
+				params[key] = values[0]
+			}
+		}
+	} else if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&params)
+	}
+
+	a.mu.Lock()
+	a.calls = append(a.calls, apiCall{Method: method, Params: params})
+	fn := a.responses[method]
+	a.mu.Unlock()
+
+	var result interface{} = true
+	if fn != nil {
+		var errResp *APIResponse
+		result, errResp = fn(params)
+		if errResp != nil {
+			w.WriteHeader(errResp.ErrorCode)
+			_ = json.NewEncoder(w).Encode(errResp)
+			return
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(APIResponse{Ok: true, Result: result})
+}
+
+func TestUsernameCachesGetMe(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.on("getMe", User{ID: 1, IsBot: true, FirstName: "Test", Username: "test_bot"})
+
+	for i := 0; i < 2; i++ {
+		username, err := bot.Username(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if username != "test_bot" {
+			t.Errorf("Expected username test_bot, got %q", username)
+		}
+	}
+	if calls := api.callsTo("getMe"); len(calls) != 1 {
+		t.Errorf("Expected getMe to be called once, got %d", len(calls))
+	}
+}
+
+func TestSetMyCommandsAdvanced(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	err := bot.SetMyCommandsAdvanced([]BotCommand{{Command: "start", Description: "Start"}}, &SetMyCommandsOptions{
+		Scope:        &BotCommandScope{Type: "all_private_chats"},
+		LanguageCode: "de",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	params := api.callsTo("setMyCommands")[0].Params
+	commands, _ := params["commands"].([]interface{})
+	scope, _ := params["scope"].(map[string]interface{})
+	if len(commands) != 1 || scope["type"] != "all_private_chats" || params["language_code"] != "de" {
+		t.Errorf("Unexpected setMyCommands parameters: %v", params)
+	}
+}
@@ -0,0 +1,159 @@
+package gotele
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Command is a bot command parsed from the bot_command entity at the start of a message
+type Command struct {
+	Name    string   // Command without the slash and the @botname suffix
+	Mention string   // Bot username the command was addressed to, empty if none
+	RawArgs string   // Text after the command, with surrounding whitespace trimmed
+	Args    []string // RawArgs split like a shell would, honouring quotes and backslashes
+}
+
+// ParseCommand extracts the command a message starts with. The @botname suffix is stripped
+// when it matches botUsername; commands addressed to any other bot are not reported,
+// and neither are addressed commands when botUsername is empty
+func ParseCommand(message *Message, botUsername string) (*Command, bool) {
+	command, ok := parseCommand(message)
+	if !ok || command.Mention != "" && !strings.EqualFold(command.Mention, botUsername) {
+		return nil, false
+	}
+	return command, true
+}
+
+// parseCommand extracts the command a message starts with, whoever it is addressed to
+func parseCommand(message *Message) (*Command, bool) {
+	if message == nil {
+		return nil, false
+	}
+
+	text, entities := message.Text, message.Entities
+	if text == "" {
+		text, entities = message.Caption, message.CaptionEntities
+	}
+
+	for _, entity := range entities {
+		if entity.Type != "bot_command" || entity.Offset != 0 {
+			continue
+		}
+
+		command := EntityText(text, entity)
+		_, end := utf16Range(text, 0, entity.Length)
+		name, mention, _ := strings.Cut(strings.TrimPrefix(command, "/"), "@")
+
+		rawArgs := strings.TrimSpace(text[end:])
+		return &Command{
+			Name:    name,
+			Mention: mention,
+			RawArgs: rawArgs,
+			Args:    SplitArgs(rawArgs),
+		}, true
+	}
+	return nil, false
+}
+
+// SplitArgs splits s into words like a shell: single and double quotes group words and a
+// backslash escapes the next character. An unterminated quote runs to the end of s
+func SplitArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+type commandKey struct{}
+
+// CommandFromContext returns the command a handler registered with Command was called for
+func CommandFromContext(ctx context.Context) (*Command, bool) {
+	command, ok := ctx.Value(commandKey{}).(*Command)
+	return command, ok
+}
+
+// Command registers a handler for messages starting with /name. The name is matched case
+// insensitively and the parsed command is available through CommandFromContext
+func (g *Group) Command(name string, handler HandlerFunc, filters ...Filter) {
+	g.handleMatch(UpdateTypeMessage, func(ctx context.Context, u *Update) (context.Context, bool) {
+		return g.router.matchCommand(ctx, u, name)
+	}, handler, filters)
+}
+
+// matchCommand reports whether the update is the command name. On a match the returned
+// context carries the parsed command
+func (r *Router) matchCommand(ctx context.Context, u *Update, name string) (context.Context, bool) {
+	command, ok := parseCommand(u.Message)
+	if !ok || !strings.EqualFold(command.Name, name) {
+		return ctx, false
+	}
+	// The username, which may need getMe, only matters for commands addressed to a bot
+	if command.Mention != "" && !strings.EqualFold(command.Mention, r.username(ctx)) {
+		return ctx, false
+	}
+	return context.WithValue(ctx, commandKey{}, command), true
+}
+
+// SetUsername sets the bot username commands may be addressed to. Without it the router asks
+// the bot from the handler context, see BotFromContext
+func (r *Router) SetUsername(username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.botUsername = username
+}
+
+// username returns the bot username for command matching, or empty when it is unknown.
+// A failed getMe is retried on the next addressed command
+func (r *Router) username(ctx context.Context) string {
+	r.mu.Lock()
+	username := r.botUsername
+	r.mu.Unlock()
+	if username != "" {
+		return username
+	}
+
+	bot, ok := BotFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	username, err := bot.Username(ctx)
+	if err != nil {
+		return ""
+	}
+	return username
+}
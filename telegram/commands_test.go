@@ -0,0 +1,130 @@
+package gotele
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func commandMessage(text string, length int) *Message {
+	return &Message{
+		Text:     text,
+		Entities: []MessageEntity{{Type: "bot_command", Offset: 0, Length: length}},
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	command, ok := ParseCommand(commandMessage(`/help@OurBot arg1 "quoted arg"`, 12), "ourbot")
+	if !ok {
+		t.Fatal("Expected a command")
+	}
+	if command.Name != "help" || command.Mention != "OurBot" {
+		t.Errorf("Unexpected command: %+v", command)
+	}
+	if command.RawArgs != `arg1 "quoted arg"` {
+		t.Errorf("Unexpected raw args %q", command.RawArgs)
+	}
+	if !reflect.DeepEqual(command.Args, []string{"arg1", "quoted arg"}) {
+		t.Errorf("Unexpected args %q", command.Args)
+	}
+}
+
+func TestParseCommandOtherBot(t *testing.T) {
+	if _, ok := ParseCommand(commandMessage("/start@OtherBot", 15), "OurBot"); ok {
+		t.Error("Expected command for another bot to be ignored")
+	}
+	if _, ok := ParseCommand(commandMessage("/start@OurBot", 13), ""); ok {
+		t.Error("Expected addressed command to be ignored when the username is unknown")
+	}
+	if _, ok := ParseCommand(&Message{Text: "/start"}, "OurBot"); ok {
+		t.Error("Expected text without a bot_command entity to be ignored")
+	}
+}
+
+func TestParseCommandUTF16Offsets(t *testing.T) {
+	// The emoji takes two UTF-16 code units but four bytes
+	message := &Message{
+		Caption:         "/echo 😀 hi",
+		CaptionEntities: []MessageEntity{{Type: "bot_command", Offset: 0, Length: 5}},
+	}
+	command, ok := ParseCommand(message, "")
+	if !ok || command.Name != "echo" || command.RawArgs != "😀 hi" {
+		t.Errorf("Unexpected command: %+v", command)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := map[string][]string{
+		"":                    nil,
+		"a  b":                {"a", "b"},
+		`"a b" 'c d'`:         {"a b", "c d"},
+		`a\ b "x\"y"`:         {"a b", `x"y`},
+		`'it''s' ""`:          {"its", ""},
+		`"unterminated quote`: {"unterminated quote"},
+	}
+	for input, expected := range tests {
+		if got := SplitArgs(input); !reflect.DeepEqual(got, expected) {
+			t.Errorf("SplitArgs(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestRouterCommand(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.on("getMe", User{ID: 1, IsBot: true, Username: "OurBot"})
+
+	var got *Command
+	router := NewRouter()
+	router.Command("start", func(ctx context.Context, u *Update) error {
+		got, _ = CommandFromContext(ctx)
+		return nil
+	})
+	notFound := 0
+	router.NotFound(func(ctx context.Context, u *Update) error {
+		notFound++
+		return nil
+	})
+
+	ctx := ContextWithBot(context.Background(), bot)
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/start@OtherBot", 15)})
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/help", 5)})
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/START@ourbot ref", 13)})
+
+	if got == nil || got.Name != "START" || got.RawArgs != "ref" {
+		t.Errorf("Unexpected command: %+v", got)
+	}
+	if notFound != 2 {
+		t.Errorf("Expected 2 unmatched updates, got %d", notFound)
+	}
+	if calls := api.callsTo("getMe"); len(calls) != 1 {
+		t.Errorf("Expected getMe to be called once, got %d", len(calls))
+	}
+}
+
+func TestRouterCommandResolvesUsernameLazily(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.handle("getMe", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		return nil, &APIResponse{ErrorCode: 500, Description: "Internal Server Error"}
+	})
+
+	handled := 0
+	router := NewRouter()
+	router.Command("start", func(ctx context.Context, u *Update) error {
+		handled++
+		return nil
+	})
+
+	ctx := ContextWithBot(context.Background(), bot)
+	_ = router.HandleUpdate(ctx, &Update{Message: &Message{Text: "hello", Chat: Chat{ID: 1}}})
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/help", 5)})
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/start", 6)})
+	if handled != 1 || len(api.callsTo("getMe")) != 0 {
+		t.Errorf("Expected no getMe for plain text and unaddressed commands, handled %d, getMe %d", handled, len(api.callsTo("getMe")))
+	}
+
+	// Addressed commands need the username and are ignored while getMe fails
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage("/start@OurBot", 13)})
+	if handled != 1 || len(api.callsTo("getMe")) != 1 {
+		t.Errorf("Expected one getMe for the addressed command, handled %d", handled)
+	}
+}
@@ -1 +1,105 @@
-package gotele
\ No newline at end of file
+package gotele
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RunMode selects how Run receives updates
+type RunMode string
+
+const (
+	// RunModeAuto uses a webhook when Webhook.URL is set and polling otherwise
+	RunModeAuto RunMode = ""
+	// RunModePolling receives updates with a Poller
+	RunModePolling RunMode = "polling"
+	// RunModeWebhook receives updates with a webhook server
+	RunModeWebhook RunMode = "webhook"
+)
+
+// RunConfig configures Run
+type RunConfig struct {
+	Mode RunMode
+
+	// Polling configures polling mode. Its Handler is replaced by the handler passed to Run
+	Polling *PollerOptions
+	// DropPendingUpdates is passed to deleteWebhook when switching to polling mode
+	DropPendingUpdates bool
+
+	// Webhook configures webhook mode. Its UpdateHandler is replaced by the handler passed to Run
+	Webhook *WebhookServer
+}
+
+// RunConfigFromEnv builds a RunConfig from environment variables:
+// BOT_MODE (polling or webhook), BOT_WEBHOOK_URL, BOT_WEBHOOK_PORT, BOT_WEBHOOK_PATH and BOT_WEBHOOK_SECRET
+func RunConfigFromEnv() *RunConfig {
+	config := &RunConfig{Mode: RunMode(strings.ToLower(os.Getenv("BOT_MODE")))}
+
+	if url := os.Getenv("BOT_WEBHOOK_URL"); url != "" {
+		port := os.Getenv("BOT_WEBHOOK_PORT")
+		if port == "" {
+			port = "8080"
+		}
+		config.Webhook = &WebhookServer{
+			URL:         url,
+			Port:        port,
+			Path:        os.Getenv("BOT_WEBHOOK_PATH"),
+			SecretToken: os.Getenv("BOT_WEBHOOK_SECRET"),
+		}
+	}
+
+	return config
+}
+
+// resolvedMode returns the mode Run uses for this configuration
+func (c *RunConfig) resolvedMode() RunMode {
+	if c.Mode != RunModeAuto {
+		return c.Mode
+	}
+	if c.Webhook != nil && c.Webhook.URL != "" {
+		return RunModeWebhook
+	}
+	return RunModePolling
+}
+
+// Run receives updates and passes them to handler until ctx is cancelled, using a webhook
+// or long polling as configured. Polling mode deletes any active webhook first and stops with
+// an error matching ErrConflict when another instance is receiving updates for the bot
+func (b *Bot) Run(ctx context.Context, handler HandlerFunc, config *RunConfig) error {
+	if config == nil {
+		config = &RunConfig{}
+	}
+
+	switch config.resolvedMode() {
+	case RunModeWebhook:
+		if config.Webhook == nil || config.Webhook.URL == "" {
+			return fmt.Errorf("webhook mode requires a webhook URL")
+		}
+		server := *config.Webhook
+		server.UpdateHandler = handler
+		return b.RunWebhookServer(ctx, &server)
+
+	case RunModePolling:
+		if err := b.DeleteWebhookWithContext(ctx, config.DropPendingUpdates); err != nil {
+			return fmt.Errorf("failed to delete webhook before polling: %w", err)
+		}
+
+		var options PollerOptions
+		if config.Polling != nil {
+			options = *config.Polling
+		}
+		options.Handler = handler
+
+		err := b.NewPoller(&options).Run(ctx)
+		if errors.Is(err, ErrConflict) {
+			return fmt.Errorf("polling stopped, make sure only one bot instance is running: %w", err)
+		}
+		return err
+
+	default:
+		return fmt.Errorf("unknown run mode %q", config.Mode)
+	}
+}
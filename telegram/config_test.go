@@ -0,0 +1,102 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPollingDeletesWebhook(t *testing.T) {
+	bot, api := newTestBot(t)
+	serveUpdates(api, Update{UpdateID: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := 0
+	err := bot.Run(ctx, func(ctx context.Context, u *Update) error {
+		handled++
+		cancel()
+		return nil
+	}, &RunConfig{DropPendingUpdates: true})
+	if err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+
+	if handled != 1 {
+		t.Errorf("Expected 1 handled update, got %d", handled)
+	}
+	methods := api.methods()
+	if methods[0] != "deleteWebhook" {
+		t.Errorf("Expected deleteWebhook before polling, got %v", methods)
+	}
+	if api.callsTo("deleteWebhook")[0].Params["drop_pending_updates"] != true {
+		t.Error("Expected drop_pending_updates to be passed to deleteWebhook")
+	}
+}
+
+func TestRunPollingConflict(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		return nil, &APIResponse{ErrorCode: 409, Description: "Conflict: terminated by other getUpdates request; make sure that only one bot instance is running"}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.Run(context.Background(), func(ctx context.Context, u *Update) error { return nil }, nil)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "terminated by other getUpdates request") {
+			t.Errorf("Expected Telegram's description in error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Run to stop on conflict instead of retrying")
+	}
+
+	if calls := len(api.callsTo("getUpdates")); calls != 1 {
+		t.Errorf("Expected a single getUpdates call, got %d", calls)
+	}
+}
+
+func TestRunConfigMode(t *testing.T) {
+	if mode := (&RunConfig{}).resolvedMode(); mode != RunModePolling {
+		t.Errorf("Expected polling by default, got %q", mode)
+	}
+	if mode := (&RunConfig{Webhook: &WebhookServer{URL: "https://example.com/hook"}}).resolvedMode(); mode != RunModeWebhook {
+		t.Errorf("Expected webhook when a URL is configured, got %q", mode)
+	}
+	if mode := (&RunConfig{Mode: RunModePolling, Webhook: &WebhookServer{URL: "https://example.com/hook"}}).resolvedMode(); mode != RunModePolling {
+		t.Errorf("Expected explicit mode to win, got %q", mode)
+	}
+
+	bot := NewBot("test_token")
+	if err := bot.Run(context.Background(), nil, &RunConfig{Mode: RunModeWebhook}); err == nil {
+		t.Error("Expected error for webhook mode without URL")
+	}
+	if err := bot.Run(context.Background(), nil, &RunConfig{Mode: "carrier-pigeon"}); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestRunConfigFromEnv(t *testing.T) {
+	t.Setenv("BOT_MODE", "Webhook")
+	t.Setenv("BOT_WEBHOOK_URL", "https://example.com/hook")
+	t.Setenv("BOT_WEBHOOK_PORT", "")
+	t.Setenv("BOT_WEBHOOK_PATH", "/hook")
+	t.Setenv("BOT_WEBHOOK_SECRET", "secret123")
+
+	config := RunConfigFromEnv()
+	if config.Mode != RunModeWebhook {
+		t.Errorf("Expected webhook mode, got %q", config.Mode)
+	}
+	if config.Webhook == nil || config.Webhook.Port != "8080" || config.Webhook.Path != "/hook" || config.Webhook.SecretToken != "secret123" {
+		t.Errorf("Unexpected webhook config: %+v", config.Webhook)
+	}
+}
@@ -0,0 +1,247 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ConversationOptions configures a Conversation
+type ConversationOptions struct {
+	Storage       StateStorage  // Defaults to a MemoryStateStorage
+	Timeout       time.Duration // Inactivity after which the conversation ends, 0 to never expire
+	CancelCommand string        // Command that ends the conversation, such as "cancel". Empty to disable
+	OnCancel      HandlerFunc   // Called when the cancel command ended a conversation, optional
+	OnTimeout     HandlerFunc   // Called with the first update after a conversation expired, optional
+}
+
+// Conversation is a multi-step flow modelled as a finite state machine. Each user in a chat
+// has their own state; an update from a user in a state is handled by that state's handler.
+// Updates from users outside the conversation that match no entry point are left to other routes
+type Conversation struct {
+	options ConversationOptions
+	entries []conversationEntry
+	states  map[string]conversationStep
+	router  *Router
+}
+
+type conversationEntry struct {
+	command string // Empty when the entry is selected by filters only
+	filters []Filter
+	handler HandlerFunc
+}
+
+type conversationStep struct {
+	handler HandlerFunc
+	next    []string // Allowed transitions, empty for any state
+}
+
+// NewConversation creates a conversation. Options may be nil
+func NewConversation(options *ConversationOptions) *Conversation {
+	c := &Conversation{states: map[string]conversationStep{}}
+	if options != nil {
+		c.options = *options
+	}
+	if c.options.Storage == nil {
+		c.options.Storage = NewMemoryStateStorage()
+	}
+	return c
+}
+
+// Entry adds an entry point matched by filters. The handler starts the conversation by calling
+// Transition on the session, see ConversationFromContext
+func (c *Conversation) Entry(handler HandlerFunc, filters ...Filter) {
+	c.entries = append(c.entries, conversationEntry{filters: filters, handler: handler})
+}
+
+// EntryCommand adds an entry point for the command name
+func (c *Conversation) EntryCommand(name string, handler HandlerFunc, filters ...Filter) {
+	c.entries = append(c.entries, conversationEntry{command: name, filters: filters, handler: handler})
+}
+
+// State declares a state and its handler. Next lists the states the handler may move to;
+// without it any declared state is allowed
+func (c *Conversation) State(name string, handler HandlerFunc, next ...string) {
+	c.states[name] = conversationStep{handler: handler, next: next}
+}
+
+// Conversation routes updates through the conversation. Register it in a group with a higher
+// priority than the routes it should take precedence over
+func (g *Group) Conversation(c *Conversation) {
+	c.router = g.router
+	g.handleMatch("", c.match, c.handle, nil)
+}
+
+type conversationAction int
+
+const (
+	conversationEntered conversationAction = iota
+	conversationContinued
+	conversationCancelled
+	conversationExpired
+)
+
+// ConversationSession is the state of the conversation an update belongs to
+type ConversationSession struct {
+	Key StateKey
+
+	conversation *Conversation
+	state        ConversationState
+	ended        bool
+	action       conversationAction
+	handler      HandlerFunc
+}
+
+type conversationKey struct{}
+
+// ConversationFromContext returns the conversation session of the update being handled
+func ConversationFromContext(ctx context.Context) (*ConversationSession, bool) {
+	session, ok := ctx.Value(conversationKey{}).(*ConversationSession)
+	return session, ok
+}
+
+// State returns the current state, empty in an entry point handler before Transition
+func (s *ConversationSession) State() string {
+	return s.state.State
+}
+
+// Transition moves the conversation to the state next once the handler returns successfully
+func (s *ConversationSession) Transition(next string) error {
+	if _, ok := s.conversation.states[next]; !ok {
+		return fmt.Errorf("conversation has no state %q", next)
+	}
+	if current, ok := s.conversation.states[s.state.State]; ok && len(current.next) > 0 {
+		allowed := false
+		for _, state := range current.next {
+			allowed = allowed || state == next
+		}
+		if !allowed {
+			return fmt.Errorf("conversation cannot move from state %q to %q", s.state.State, next)
+		}
+	}
+	s.state.State = next
+	s.ended = false
+	return nil
+}
+
+// End ends the conversation once the handler returns successfully
+func (s *ConversationSession) End() {
+	s.ended = true
+}
+
+// Data decodes the conversation data into v. Without stored data v is left untouched
+func (s *ConversationSession) Data(v interface{}) error {
+	if len(s.state.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(s.state.Data, v); err != nil {
+		return fmt.Errorf("failed to decode conversation data: %w", err)
+	}
+	return nil
+}
+
+// SetData replaces the conversation data with v, which must be JSON encodable
+func (s *ConversationSession) SetData(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation data: %w", err)
+	}
+	s.state.Data = data
+	return nil
+}
+
+// match selects the update if its user is in the conversation or it matches an entry point
+func (c *Conversation) match(ctx context.Context, u *Update) (context.Context, bool) {
+	chat, user := u.EffectiveChat(), u.EffectiveUser()
+	if chat == nil || user == nil {
+		return ctx, false
+	}
+	key := StateKey{ChatID: chat.ID, UserID: user.ID}
+	session := &ConversationSession{Key: key, conversation: c}
+
+	stored, err := c.options.Storage.GetState(ctx, key)
+	if err != nil {
+		// Let the handler report the storage failure
+		session.handler = func(context.Context, *Update) error {
+			return fmt.Errorf("failed to load conversation state: %w", err)
+		}
+		return context.WithValue(ctx, conversationKey{}, session), true
+	}
+
+	if stored != nil {
+		step, known := c.states[stored.State]
+		expired := c.options.Timeout > 0 && time.Since(stored.UpdatedAt) > c.options.Timeout
+		switch {
+		case expired && c.options.OnTimeout != nil:
+			session.action, session.handler = conversationExpired, c.options.OnTimeout
+		case expired || !known:
+			// Forget stale states and treat the update as coming from outside the conversation
+			_ = c.options.Storage.DeleteState(ctx, key)
+			stored = nil
+		case c.options.CancelCommand != "" && c.isCommand(ctx, u, c.options.CancelCommand):
+			session.action, session.handler = conversationCancelled, c.options.OnCancel
+		default:
+			session.action, session.handler = conversationContinued, step.handler
+			session.state = *stored
+		}
+		if stored != nil {
+			return context.WithValue(ctx, conversationKey{}, session), true
+		}
+	}
+
+	for _, entry := range c.entries {
+		if !matchAll(entry.filters, u) {
+			continue
+		}
+		entryCtx := ctx
+		if entry.command != "" {
+			var ok bool
+			if entryCtx, ok = c.router.matchCommand(ctx, u, entry.command); !ok {
+				continue
+			}
+		}
+		session.action, session.handler = conversationEntered, entry.handler
+		return context.WithValue(entryCtx, conversationKey{}, session), true
+	}
+	return ctx, false
+}
+
+func (c *Conversation) isCommand(ctx context.Context, u *Update, name string) bool {
+	_, ok := c.router.matchCommand(ctx, u, name)
+	return ok
+}
+
+// handle runs the selected handler and stores the resulting state
+func (c *Conversation) handle(ctx context.Context, u *Update) error {
+	session, _ := ConversationFromContext(ctx)
+
+	if session.action == conversationCancelled || session.action == conversationExpired {
+		if err := c.options.Storage.DeleteState(ctx, session.Key); err != nil {
+			return fmt.Errorf("failed to delete conversation state: %w", err)
+		}
+		if session.handler == nil {
+			return nil
+		}
+		return session.handler(ctx, u)
+	}
+
+	if err := session.handler(ctx, u); err != nil {
+		return err
+	}
+
+	switch {
+	case session.ended && session.action != conversationEntered:
+		if err := c.options.Storage.DeleteState(ctx, session.Key); err != nil {
+			return fmt.Errorf("failed to delete conversation state: %w", err)
+		}
+	case session.ended || session.state.State == "":
+		// The entry point did not start the conversation
+	default:
+		session.state.UpdatedAt = time.Now()
+		if err := c.options.Storage.SetState(ctx, session.Key, &session.state); err != nil {
+			return fmt.Errorf("failed to save conversation state: %w", err)
+		}
+	}
+	return nil
+}
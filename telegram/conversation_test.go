@@ -0,0 +1,161 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type signupData struct {
+	Name string `json:"name"`
+	Age  string `json:"age"`
+}
+
+func userMessage(userID int64, text string) *Update {
+	return &Update{Message: &Message{
+		Text: text,
+		Chat: Chat{ID: 10, Type: "private"},
+		From: &User{ID: userID},
+	}}
+}
+
+func commandUpdate(userID int64, command string) *Update {
+	update := userMessage(userID, command)
+	update.Message.Entities = []MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	return update
+}
+
+func newSignupConversation(storage StateStorage, done *signupData) *Conversation {
+	conv := NewConversation(&ConversationOptions{Storage: storage, CancelCommand: "cancel"})
+	conv.EntryCommand("signup", func(ctx context.Context, u *Update) error {
+		session, _ := ConversationFromContext(ctx)
+		return session.Transition("name")
+	})
+	conv.State("name", func(ctx context.Context, u *Update) error {
+		session, _ := ConversationFromContext(ctx)
+		if err := session.SetData(signupData{Name: u.Message.Text}); err != nil {
+			return err
+		}
+		return session.Transition("age")
+	}, "age")
+	conv.State("age", func(ctx context.Context, u *Update) error {
+		session, _ := ConversationFromContext(ctx)
+		var data signupData
+		if err := session.Data(&data); err != nil {
+			return err
+		}
+		data.Age = u.Message.Text
+		*done = data
+		session.End()
+		return nil
+	})
+	return conv
+}
+
+func TestConversationFlow(t *testing.T) {
+	storage := NewMemoryStateStorage()
+	var done signupData
+	var other []string
+
+	router := NewRouter()
+	router.SetUsername("OurBot")
+	router.NewGroup(10).Conversation(newSignupConversation(storage, &done))
+	router.Message(recordTo(&other, "other"))
+
+	ctx := context.Background()
+	_ = router.HandleUpdate(ctx, userMessage(1, "hello"))
+	if err := router.HandleUpdate(ctx, commandUpdate(1, "/signup")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = router.HandleUpdate(ctx, userMessage(2, "not in a conversation"))
+	_ = router.HandleUpdate(ctx, userMessage(1, "Ada"))
+
+	state, _ := storage.GetState(ctx, StateKey{ChatID: 10, UserID: 1})
+	if state == nil || state.State != "age" {
+		t.Fatalf("Expected state age, got %+v", state)
+	}
+
+	_ = router.HandleUpdate(ctx, userMessage(1, "36"))
+
+	if done != (signupData{Name: "Ada", Age: "36"}) {
+		t.Errorf("Unexpected conversation result: %+v", done)
+	}
+	if state, _ := storage.GetState(ctx, StateKey{ChatID: 10, UserID: 1}); state != nil {
+		t.Errorf("Expected state to be deleted, got %+v", state)
+	}
+	if len(other) != 2 {
+		t.Errorf("Expected 2 updates outside the conversation, got %v", other)
+	}
+}
+
+func TestConversationCancelAndTimeout(t *testing.T) {
+	storage := NewMemoryStateStorage()
+	key := StateKey{ChatID: 10, UserID: 1}
+	ctx := context.Background()
+
+	var calls []string
+	conv := newSignupConversation(storage, &signupData{})
+	conv.options.OnCancel = recordTo(&calls, "cancel")
+	conv.options.OnTimeout = recordTo(&calls, "timeout")
+	conv.options.Timeout = time.Minute
+
+	router := NewRouter()
+	router.SetUsername("OurBot")
+	router.Conversation(conv)
+
+	_ = storage.SetState(ctx, key, &ConversationState{State: "name", UpdatedAt: time.Now()})
+	_ = router.HandleUpdate(ctx, commandUpdate(1, "/cancel"))
+
+	_ = storage.SetState(ctx, key, &ConversationState{State: "name", UpdatedAt: time.Now().Add(-time.Hour)})
+	_ = router.HandleUpdate(ctx, userMessage(1, "late"))
+
+	if len(calls) != 2 || calls[0] != "cancel" || calls[1] != "timeout" {
+		t.Errorf("Expected cancel and timeout handlers, got %v", calls)
+	}
+	if state, _ := storage.GetState(ctx, key); state != nil {
+		t.Errorf("Expected state to be deleted, got %+v", state)
+	}
+}
+
+func TestConversationTransitionRules(t *testing.T) {
+	conv := NewConversation(nil)
+	conv.State("a", nil, "b")
+	conv.State("b", nil)
+	conv.State("c", nil)
+
+	session := &ConversationSession{conversation: conv, state: ConversationState{State: "a"}}
+	if err := session.Transition("c"); err == nil {
+		t.Error("Expected undeclared transition to fail")
+	}
+	if err := session.Transition("missing"); err == nil {
+		t.Error("Expected transition to an unknown state to fail")
+	}
+	if err := session.Transition("b"); err != nil || session.State() != "b" {
+		t.Errorf("Expected transition to b, got %v", err)
+	}
+}
+
+func TestConversationHandlerErrorKeepsState(t *testing.T) {
+	storage := NewMemoryStateStorage()
+	key := StateKey{ChatID: 10, UserID: 1}
+	ctx := context.Background()
+	failure := errors.New("invalid input")
+
+	conv := NewConversation(&ConversationOptions{Storage: storage})
+	conv.State("name", func(ctx context.Context, u *Update) error {
+		session, _ := ConversationFromContext(ctx)
+		session.End()
+		return failure
+	})
+	router := NewRouter()
+	router.Conversation(conv)
+
+	_ = storage.SetState(ctx, key, &ConversationState{State: "name", UpdatedAt: time.Now()})
+	if err := router.HandleUpdate(ctx, userMessage(1, "x")); !errors.Is(err, failure) {
+		t.Errorf("Expected handler error, got %v", err)
+	}
+	if state, _ := storage.GetState(ctx, key); state == nil || state.State != "name" {
+		t.Errorf("Expected state to be kept, got %+v", state)
+	}
+}
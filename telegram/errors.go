@@ -1,9 +1,42 @@
 package gotele
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
+// ErrConflict matches 409 Conflict errors, returned when another getUpdates request is
+// running for the bot or polling is attempted while a webhook is active
+var ErrConflict = errors.New("conflict: updates for this bot are already being received elsewhere")
+
+// ErrNoChat is returned by Context helpers when the update does not belong to a chat
+var ErrNoChat = errors.New("update has no chat")
+
+// ErrNotCallbackQuery is returned by Context.Answer for updates other than callback queries
+var ErrNotCallbackQuery = errors.New("update is not a callback query")
+
+// ErrSessionConflict is returned when a session was changed by another update after it was loaded
+var ErrSessionConflict = errors.New("session was modified concurrently")
+
+// ErrCallbackDataTooLong is returned when encoded callback data exceeds 64 bytes and no
+// CallbackStore is configured
+var ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+
+// ErrInvalidCallbackData is returned for callback data that is malformed, expired or fails
+// signature verification
+var ErrInvalidCallbackData = errors.New("invalid callback data")
+
+// ErrInvalidKeyboard is returned by keyboard builders for keyboards Telegram would reject
+var ErrInvalidKeyboard = errors.New("invalid keyboard")
+
+// ErrSplitParseMode is returned by SendLongMessage when given a parse mode: markup cannot be
+// split safely, so long messages must carry entities, see Format
+var ErrSplitParseMode = errors.New("long messages need entities instead of a parse mode")
+
 // APIError represents a Telegram Bot API error response
 type APIError struct {
 	ErrorCode   int                    `json:"error_code"`
@@ -16,6 +49,11 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("telegram API error %d: %s", e.ErrorCode, e.Description)
 }
 
+// Is reports whether the error matches target, so that errors.Is(err, ErrConflict) detects conflicts
+func (e *APIError) Is(target error) bool {
+	return target == ErrConflict && e.ErrorCode == 409
+}
+
 // IsRetryable returns true if the error is retryable
 func (e *APIError) IsRetryable() bool {
 	// Common retryable error codes
@@ -63,7 +101,95 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d %s: %s", e.StatusCode, e.Status, e.Body)
 }
 
+// Is reports whether the error matches target, so that errors.Is(err, ErrConflict) detects conflicts
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrConflict && e.StatusCode == 409
+}
+
 // IsRetryable returns true if the HTTP error is retryable
 func (e *HTTPError) IsRetryable() bool {
 	return e.StatusCode >= 500 || e.StatusCode == 429
 }
+
+// WebhookRegistrationError is returned when getWebhookInfo does not confirm a webhook registration
+type WebhookRegistrationError struct {
+	ExpectedURL      string
+	ActualURL        string
+	LastErrorMessage string
+}
+
+// Error implements the error interface
+func (e *WebhookRegistrationError) Error() string {
+	if e.ActualURL != e.ExpectedURL {
+		return fmt.Sprintf("webhook registration mismatch: expected URL %q, got %q", e.ExpectedURL, e.ActualURL)
+	}
+	return fmt.Sprintf("webhook registered at %q reports error: %s", e.ActualURL, e.LastErrorMessage)
+}
+
+// PanicError is returned by the Recover middleware when a handler panics
+type PanicError struct {
+	UpdateID int
+	Value    interface{} // Value passed to panic
+	Stack    []byte      // Stack trace of the panicking goroutine
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked on update %d: %v", e.UpdateID, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// isMessageNotModified reports whether an edit failed only because it would not change the message
+func isMessageNotModified(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.Description, "message is not modified")
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return strings.Contains(httpErr.Body, "message is not modified")
+	}
+	return false
+}
+
+// isRetryableError reports whether a failed request may succeed when repeated.
+// Network errors are retryable, API errors only for rate limits and server failures
+func isRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsRetryable()
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.IsRetryable()
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// retryAfter returns the delay requested by a rate limited response, or 0
+func retryAfter(err error) time.Duration {
+	var parameters map[string]interface{}
+
+	var apiErr *APIError
+	var httpErr *HTTPError
+	if errors.As(err, &apiErr) {
+		parameters = apiErr.Parameters
+	} else if errors.As(err, &httpErr) {
+		var body struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+		if json.Unmarshal([]byte(httpErr.Body), &body) == nil {
+			parameters = body.Parameters
+		}
+	}
+
+	if seconds, ok := parameters["retry_after"].(float64); ok {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
@@ -1,6 +1,8 @@
 package gotele
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -85,3 +87,16 @@ func TestAPIResponseToError(t *testing.T) {
 		t.Errorf("Expected description 'Bad Request', got %q", apiErr.Description)
 	}
 }
+
+func TestErrConflict(t *testing.T) {
+	conflict := &HTTPError{StatusCode: 409, Status: "409 Conflict"}
+	if !errors.Is(fmt.Errorf("polling: %w", conflict), ErrConflict) {
+		t.Error("Expected HTTP 409 to match ErrConflict")
+	}
+	if !errors.Is(&APIError{ErrorCode: 409}, ErrConflict) {
+		t.Error("Expected API error 409 to match ErrConflict")
+	}
+	if errors.Is(&HTTPError{StatusCode: 500}, ErrConflict) {
+		t.Error("Expected HTTP 500 not to match ErrConflict")
+	}
+}
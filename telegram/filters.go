@@ -0,0 +1,111 @@
+package gotele
+
+import (
+	"regexp"
+)
+
+// Filter reports whether a route applies to an update
+type Filter func(u *Update) bool
+
+// And matches updates matched by all filters
+func And(filters ...Filter) Filter {
+	return func(u *Update) bool {
+		return matchAll(filters, u)
+	}
+}
+
+// Or matches updates matched by at least one filter
+func Or(filters ...Filter) Filter {
+	return func(u *Update) bool {
+		for _, filter := range filters {
+			if filter(u) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts a filter
+func Not(filter Filter) Filter {
+	return func(u *Update) bool {
+		return !filter(u)
+	}
+}
+
+// ChatType matches updates from chats of the given types ("private", "group", "supergroup", "channel")
+func ChatType(types ...string) Filter {
+	return func(u *Update) bool {
+		chat := u.EffectiveChat()
+		if chat == nil {
+			return false
+		}
+		for _, chatType := range types {
+			if chat.Type == chatType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PrivateOnly matches updates from private chats
+func PrivateOnly() Filter {
+	return ChatType("private")
+}
+
+// HasPhoto matches messages carrying a photo
+func HasPhoto() Filter {
+	return func(u *Update) bool {
+		message := u.EffectiveMessage()
+		return message != nil && len(message.Photo) > 0
+	}
+}
+
+// HasText matches messages with text
+func HasText() Filter {
+	return func(u *Update) bool {
+		message := u.EffectiveMessage()
+		return message != nil && message.Text != ""
+	}
+}
+
+// FromUsers matches updates caused by one of the given user IDs
+func FromUsers(ids ...int64) Filter {
+	allowed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return func(u *Update) bool {
+		user := u.EffectiveUser()
+		return user != nil && allowed[user.ID]
+	}
+}
+
+// TextMatches matches messages whose text, or caption for media, matches the regular expression
+func TextMatches(re *regexp.Regexp) Filter {
+	return func(u *Update) bool {
+		message := u.EffectiveMessage()
+		if message == nil {
+			return false
+		}
+		if message.Text != "" {
+			return re.MatchString(message.Text)
+		}
+		return message.Caption != "" && re.MatchString(message.Caption)
+	}
+}
+
+// Regex is TextMatches for a pattern; it panics if the pattern does not compile
+func Regex(pattern string) Filter {
+	return TextMatches(regexp.MustCompile(pattern))
+}
+
+func matchAll(filters []Filter, u *Update) bool {
+	for _, filter := range filters {
+		if !filter(u) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package gotele
+
+import (
+	"testing"
+)
+
+func TestChatTypeFilter(t *testing.T) {
+	private := &Update{Message: &Message{Chat: Chat{Type: "private"}}}
+	group := &Update{Message: &Message{Chat: Chat{Type: "supergroup"}}}
+
+	if !PrivateOnly()(private) || PrivateOnly()(group) {
+		t.Error("Expected PrivateOnly to match only private chats")
+	}
+	if !ChatType("group", "supergroup")(group) {
+		t.Error("Expected ChatType to match supergroup")
+	}
+	if ChatType("private")(&Update{InlineQuery: &InlineQuery{}}) {
+		t.Error("Expected ChatType not to match updates without a chat")
+	}
+}
+
+func TestTextMatchesFilter(t *testing.T) {
+	filter := Regex(`^order #\d+`)
+
+	if !filter(&Update{Message: &Message{Text: "order #12"}}) {
+		t.Error("Expected text to match")
+	}
+	if !filter(&Update{Message: &Message{Caption: "order #7", Photo: []PhotoSize{{}}}}) {
+		t.Error("Expected caption to match")
+	}
+	if filter(&Update{Message: &Message{Text: "hello"}}) {
+		t.Error("Expected text not to match")
+	}
+}
+
+func TestFilterCombinators(t *testing.T) {
+	update := &Update{Message: &Message{From: &User{ID: 1}, Text: "hi"}}
+
+	if !And(FromUsers(1), HasText())(update) {
+		t.Error("Expected And to match")
+	}
+	if And(FromUsers(1), HasPhoto())(update) {
+		t.Error("Expected And not to match")
+	}
+	if !Or(HasPhoto(), FromUsers(1, 2))(update) {
+		t.Error("Expected Or to match")
+	}
+	if Not(HasText())(update) {
+		t.Error("Expected Not to invert")
+	}
+}
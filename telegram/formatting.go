@@ -0,0 +1,323 @@
+package gotele
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse modes for message text and captions
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeMarkdown   = "Markdown" // Legacy, kept for backward compatibility by Telegram
+	ParseModeHTML       = "HTML"
+)
+
+// markdownV2Escaper escapes every character MarkdownV2 reserves outside entities
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`,
+	"`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`,
+	"{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// EscapeMarkdownV2 escapes text for use outside entities in MarkdownV2
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Escaper.Replace(text)
+}
+
+// EscapeMarkdownV2Code escapes text for use inside MarkdownV2 code and pre entities
+func EscapeMarkdownV2Code(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(text)
+}
+
+// EscapeMarkdownV2URL escapes a URL for use inside the parentheses of a MarkdownV2 link
+func EscapeMarkdownV2URL(url string) string {
+	return strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace(url)
+}
+
+// EscapeMarkdown escapes text for the legacy Markdown parse mode
+func EscapeMarkdown(text string) string {
+	return strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`).Replace(text)
+}
+
+// EscapeHTML escapes text for the HTML parse mode
+func EscapeHTML(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
+
+// Fragment is a piece of formatted text. Fragments nest: the parts of Bold, Italic and the other
+// container functions may be strings, other fragments or any value, which is formatted with fmt.Sprint
+type Fragment struct {
+	entity MessageEntity // Zero Type for plain text
+	parts  []interface{}
+}
+
+// Bold formats parts in bold
+func Bold(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "bold"}, parts: parts}
+}
+
+// Italic formats parts in italics
+func Italic(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "italic"}, parts: parts}
+}
+
+// Underline underlines parts
+func Underline(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "underline"}, parts: parts}
+}
+
+// Strikethrough strikes parts through
+func Strikethrough(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "strikethrough"}, parts: parts}
+}
+
+// Spoiler hides parts until tapped
+func Spoiler(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "spoiler"}, parts: parts}
+}
+
+// Blockquote quotes parts
+func Blockquote(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "blockquote"}, parts: parts}
+}
+
+// ExpandableBlockquote quotes parts, collapsed until tapped
+func ExpandableBlockquote(parts ...interface{}) Fragment {
+	return Fragment{entity: MessageEntity{Type: "expandable_blockquote"}, parts: parts}
+}
+
+// Code formats text as inline code
+func Code(text string) Fragment {
+	return Fragment{entity: MessageEntity{Type: "code"}, parts: []interface{}{text}}
+}
+
+// Pre formats text as a code block. Language may be empty
+func Pre(text, language string) Fragment {
+	return Fragment{entity: MessageEntity{Type: "pre", Language: language}, parts: []interface{}{text}}
+}
+
+// Link links text to url
+func Link(text, url string) Fragment {
+	return Fragment{entity: MessageEntity{Type: "text_link", URL: url}, parts: []interface{}{text}}
+}
+
+// Mention links text to a user, which works for users without a username
+func Mention(text string, userID int64) Fragment {
+	return Fragment{entity: MessageEntity{Type: "text_mention", User: &User{ID: userID}}, parts: []interface{}{text}}
+}
+
+// CustomEmoji shows a custom emoji. Alt must be a regular emoji shown where custom emoji are unavailable
+func CustomEmoji(alt, customEmojiID string) Fragment {
+	return Fragment{entity: MessageEntity{Type: "custom_emoji", CustomEmojiID: customEmojiID}, parts: []interface{}{alt}}
+}
+
+// FormattedText is message text with the entities formatting it, ready for the Text and Entities
+// of SendMessageOptions or the caption fields. No parse mode is needed
+type FormattedText struct {
+	Text     string
+	Entities []MessageEntity
+}
+
+// Format renders parts as plain text and entities with UTF-16 offsets
+func Format(parts ...interface{}) FormattedText {
+	return NewTextBuilder().Append(parts...).Build()
+}
+
+// FormatHTML renders parts for the HTML parse mode
+func FormatHTML(parts ...interface{}) string {
+	return NewTextBuilder().Append(parts...).HTML()
+}
+
+// FormatMarkdownV2 renders parts for the MarkdownV2 parse mode
+func FormatMarkdownV2(parts ...interface{}) string {
+	return NewTextBuilder().Append(parts...).MarkdownV2()
+}
+
+// TextBuilder accumulates formatted text piece by piece
+type TextBuilder struct {
+	parts []interface{}
+}
+
+// NewTextBuilder creates an empty text builder
+func NewTextBuilder() *TextBuilder {
+	return &TextBuilder{}
+}
+
+// Append adds parts: strings, fragments or other values formatted with fmt.Sprint
+func (tb *TextBuilder) Append(parts ...interface{}) *TextBuilder {
+	tb.parts = append(tb.parts, parts...)
+	return tb
+}
+
+// Build renders the text as plain text and entities
+func (tb *TextBuilder) Build() FormattedText {
+	var text strings.Builder
+	var entities []MessageEntity
+	offset := 0
+
+	var walk func(parts []interface{})
+	walk = func(parts []interface{}) {
+		for _, part := range parts {
+			fragment, ok := part.(Fragment)
+			if !ok {
+				s := fmt.Sprint(part)
+				text.WriteString(s)
+				offset += utf16Len(s)
+				continue
+			}
+			if fragment.entity.Type == "" {
+				walk(fragment.parts)
+				continue
+			}
+
+			// Outer entities come before the entities nested in them
+			index := len(entities)
+			entities = append(entities, fragment.entity)
+			start := offset
+			walk(fragment.parts)
+			if offset == start {
+				entities = append(entities[:index], entities[index+1:]...)
+				continue
+			}
+			entities[index].Offset = start
+			entities[index].Length = offset - start
+		}
+	}
+	walk(tb.parts)
+
+	return FormattedText{Text: text.String(), Entities: entities}
+}
+
+// HTML renders the text for the HTML parse mode
+func (tb *TextBuilder) HTML() string {
+	var out strings.Builder
+	var walk func(parts []interface{})
+	walk = func(parts []interface{}) {
+		for _, part := range parts {
+			fragment, ok := part.(Fragment)
+			if !ok {
+				out.WriteString(EscapeHTML(fmt.Sprint(part)))
+				continue
+			}
+			open, closing := htmlTags(fragment.entity)
+			out.WriteString(open)
+			walk(fragment.parts)
+			out.WriteString(closing)
+		}
+	}
+	walk(tb.parts)
+	return out.String()
+}
+
+// MarkdownV2 renders the text for the MarkdownV2 parse mode. Block quotes are put on lines of
+// their own, adding line breaks the plain text does not have where needed
+func (tb *TextBuilder) MarkdownV2() string {
+	var render func(parts []interface{}, code bool) string
+	render = func(parts []interface{}, code bool) string {
+		var out strings.Builder
+		endLine := false
+		for _, part := range parts {
+			var text string
+			quote := false
+			if fragment, ok := part.(Fragment); ok {
+				entity := fragment.entity
+				isCode := entity.Type == "code" || entity.Type == "pre"
+				quote = entity.Type == "blockquote" || entity.Type == "expandable_blockquote"
+				text = markdownV2Wrap(entity, render(fragment.parts, code || isCode))
+			} else if code {
+				text = EscapeMarkdownV2Code(fmt.Sprint(part))
+			} else {
+				text = EscapeMarkdownV2(fmt.Sprint(part))
+			}
+			if text == "" {
+				continue
+			}
+
+			// Quotes take whole lines, so they start on a new line and end theirs
+			if (endLine || quote && out.Len() > 0) && !strings.HasSuffix(out.String(), "\n") && !strings.HasPrefix(text, "\n") {
+				out.WriteString("\n")
+			}
+			if strings.HasPrefix(text, "_") && strings.HasSuffix(out.String(), "_") {
+				// Telegram ignores \r, which separates adjacent italic and underline markers
+				out.WriteString("\r")
+			}
+			out.WriteString(text)
+			endLine = quote
+		}
+		return out.String()
+	}
+	return render(tb.parts, false)
+}
+
+// htmlTags returns the opening and closing HTML tags of an entity
+func htmlTags(entity MessageEntity) (string, string) {
+	switch entity.Type {
+	case "bold":
+		return "<b>", "</b>"
+	case "italic":
+		return "<i>", "</i>"
+	case "underline":
+		return "<u>", "</u>"
+	case "strikethrough":
+		return "<s>", "</s>"
+	case "spoiler":
+		return "<tg-spoiler>", "</tg-spoiler>"
+	case "code":
+		return "<code>", "</code>"
+	case "pre":
+		if entity.Language != "" {
+			return `<pre><code class="language-` + EscapeHTML(entity.Language) + `">`, "</code></pre>"
+		}
+		return "<pre>", "</pre>"
+	case "text_link":
+		return `<a href="` + EscapeHTML(entity.URL) + `">`, "</a>"
+	case "text_mention":
+		if entity.User != nil {
+			return `<a href="tg://user?id=` + strconv.FormatInt(entity.User.ID, 10) + `">`, "</a>"
+		}
+	case "custom_emoji":
+		return `<tg-emoji emoji-id="` + EscapeHTML(entity.CustomEmojiID) + `">`, "</tg-emoji>"
+	case "blockquote":
+		return "<blockquote>", "</blockquote>"
+	case "expandable_blockquote":
+		return "<blockquote expandable>", "</blockquote>"
+	}
+	return "", ""
+}
+
+// markdownV2Wrap wraps already escaped content in the MarkdownV2 markup of an entity
+func markdownV2Wrap(entity MessageEntity, content string) string {
+	switch entity.Type {
+	case "bold":
+		return "*" + content + "*"
+	case "italic":
+		return "_" + content + "_"
+	case "underline":
+		if strings.HasSuffix(content, "_") {
+			return "__" + content + "\r__"
+		}
+		return "__" + content + "__"
+	case "strikethrough":
+		return "~" + content + "~"
+	case "spoiler":
+		return "||" + content + "||"
+	case "code":
+		return "`" + content + "`"
+	case "pre":
+		return "```" + entity.Language + "\n" + content + "\n```"
+	case "text_link":
+		return "[" + content + "](" + EscapeMarkdownV2URL(entity.URL) + ")"
+	case "text_mention":
+		if entity.User != nil {
+			return "[" + content + "](tg://user?id=" + strconv.FormatInt(entity.User.ID, 10) + ")"
+		}
+	case "custom_emoji":
+		return "![" + content + "](tg://emoji?id=" + entity.CustomEmojiID + ")"
+	case "blockquote":
+		return ">" + strings.ReplaceAll(content, "\n", "\n>")
+	case "expandable_blockquote":
+		return "**>" + strings.ReplaceAll(content, "\n", "\n>") + "||"
+	}
+	return content
+}
@@ -0,0 +1,102 @@
+package gotele
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEscapeFunctions(t *testing.T) {
+	if got := EscapeMarkdownV2(`1+1=2. (a_b) *c* \ ~!`); got != `1\+1\=2\. \(a\_b\) \*c\* \\ \~\!` {
+		t.Errorf("Unexpected MarkdownV2 escaping: %q", got)
+	}
+	if got := EscapeMarkdownV2Code("a`b\\c*"); got != "a\\`b\\\\c*" {
+		t.Errorf("Unexpected MarkdownV2 code escaping: %q", got)
+	}
+	if got := EscapeMarkdown("a_b*c`d[e]"); got != "a\\_b\\*c\\`d\\[e]" {
+		t.Errorf("Unexpected Markdown escaping: %q", got)
+	}
+	if got := EscapeHTML(`<a href="x">&</a>`); got != "&lt;a href=&quot;x&quot;&gt;&amp;&lt;/a&gt;" {
+		t.Errorf("Unexpected HTML escaping: %q", got)
+	}
+}
+
+func TestFormatEntities(t *testing.T) {
+	formatted := Format("😀 ", Bold("Hi ", Italic("𝄞 you")), "! ", Link("site", "https://example.com"),
+		" ", Pre("x := 1", "go"), Bold(""), " ", Mention("Ann", 42), " #", 7)
+
+	if formatted.Text != "😀 Hi 𝄞 you! site x := 1 Ann #7" {
+		t.Errorf("Unexpected text: %q", formatted.Text)
+	}
+	expected := []MessageEntity{
+		{Type: "bold", Offset: 3, Length: 9},
+		{Type: "italic", Offset: 6, Length: 6},
+		{Type: "text_link", Offset: 14, Length: 4, URL: "https://example.com"},
+		{Type: "pre", Offset: 19, Length: 6, Language: "go"},
+		{Type: "text_mention", Offset: 26, Length: 3, User: &User{ID: 42}},
+	}
+	if !reflect.DeepEqual(formatted.Entities, expected) {
+		t.Errorf("Unexpected entities: %+v", formatted.Entities)
+	}
+	for _, entity := range formatted.Entities[:2] {
+		if got := EntityText(formatted.Text, entity); got != map[string]string{"bold": "Hi 𝄞 you", "italic": "𝄞 you"}[entity.Type] {
+			t.Errorf("Entity %s covers %q", entity.Type, got)
+		}
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	got := FormatHTML("1 < 2 ", Bold("a & ", Italic("b")), " ", Spoiler("s"), " ", Code("<x>"),
+		" ", Pre("fn()", "go"), " ", Link("l", `https://e.com/?a="1"`), " ", CustomEmoji("👍", "123"),
+		" ", Blockquote("q"), ExpandableBlockquote("e"))
+	expected := `1 &lt; 2 <b>a &amp; <i>b</i></b> <tg-spoiler>s</tg-spoiler> <code>&lt;x&gt;</code> ` +
+		`<pre><code class="language-go">fn()</code></pre> <a href="https://e.com/?a=&quot;1&quot;">l</a> ` +
+		`<tg-emoji emoji-id="123">👍</tg-emoji> <blockquote>q</blockquote><blockquote expandable>e</blockquote>`
+	if got != expected {
+		t.Errorf("Unexpected HTML:\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestFormatMarkdownV2(t *testing.T) {
+	got := FormatMarkdownV2("Total: 5.00! ", Bold("a_b ", Italic("c")), " ", Strikethrough("d"), " ",
+		Code("`x`*"), " ", Link("a.b", "https://e.com/(x)"), " ", Mention("Ann", 42), " ",
+		CustomEmoji("👍", "123"), "\n", Blockquote("one\ntwo"))
+	expected := "Total: 5\\.00\\! *a\\_b _c_* ~d~ `\\`x\\`*` [a\\.b](https://e.com/(x\\)) " +
+		"[Ann](tg://user?id=42) ![👍](tg://emoji?id=123)\n>one\n>two"
+	if got != expected {
+		t.Errorf("Unexpected MarkdownV2:\n%s\nexpected\n%s", got, expected)
+	}
+
+	if got := FormatMarkdownV2(Underline(Italic("x"))); got != "___x_\r__" {
+		t.Errorf("Expected italic inside underline to be separated, got %q", got)
+	}
+	if got := FormatMarkdownV2(Italic("x"), Underline("y")); got != "_x_\r__y__" {
+		t.Errorf("Expected adjacent italic and underline to be separated, got %q", got)
+	}
+	if got := FormatMarkdownV2("Hi ", Blockquote("quote")); got != "Hi \n>quote" {
+		t.Errorf("Expected the quote to start a new line, got %q", got)
+	}
+	if got := FormatMarkdownV2(Blockquote("a"), "b"); got != ">a\nb" {
+		t.Errorf("Expected the quote to end its line, got %q", got)
+	}
+	if got := FormatMarkdownV2(ExpandableBlockquote("a"), "\nb"); got != "**>a||\nb" {
+		t.Errorf("Expected no extra line break after the quote, got %q", got)
+	}
+	if got := FormatMarkdownV2(Pre("a\\b", "")); got != "```\na\\\\b\n```" {
+		t.Errorf("Unexpected pre block: %q", got)
+	}
+}
+
+func TestTextBuilder(t *testing.T) {
+	builder := NewTextBuilder()
+	for i, item := range []string{"tea", "milk"} {
+		builder.Append(i+1, ". ", Bold(item), "\n")
+	}
+
+	formatted := builder.Build()
+	if formatted.Text != "1. tea\n2. milk\n" || len(formatted.Entities) != 2 || formatted.Entities[1].Offset != 10 {
+		t.Errorf("Unexpected result: %+v", formatted)
+	}
+	if got := builder.HTML(); got != "1. <b>tea</b>\n2. <b>milk</b>\n" {
+		t.Errorf("Unexpected HTML: %q", got)
+	}
+}
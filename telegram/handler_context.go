@@ -0,0 +1,195 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Context bundles an update with the bot that received it and offers helpers that act on
+// the update's chat, message or callback query. It is a context.Context itself
+type Context struct {
+	context.Context
+	Bot    *Bot
+	Update *Update
+
+	answered atomic.Bool
+}
+
+// NewContext creates a Context for an update
+func NewContext(ctx context.Context, bot *Bot, u *Update) *Context {
+	return &Context{Context: ctx, Bot: bot, Update: u}
+}
+
+// HandleContext adapts a Context handler to a HandlerFunc. The bot is taken from the handler
+// context, see BotFromContext. Callback queries the handler did not answer are answered
+// without text, so clients stop showing a progress indicator
+func HandleContext(handler func(c *Context) error) HandlerFunc {
+	return func(ctx context.Context, u *Update) error {
+		bot, ok := BotFromContext(ctx)
+		if !ok {
+			return errors.New("no bot in handler context")
+		}
+
+		c := NewContext(ctx, bot, u)
+		err := handler(c)
+		if u.CallbackQuery != nil && !c.answered.Load() && !errors.Is(err, ErrFallthrough) {
+			if answerErr := c.Answer(""); answerErr != nil && err == nil {
+				err = fmt.Errorf("failed to answer callback query: %w", answerErr)
+			}
+		}
+		return err
+	}
+}
+
+// Message returns the message the update carries, see Update.EffectiveMessage
+func (c *Context) Message() *Message {
+	return c.Update.EffectiveMessage()
+}
+
+// Chat returns the chat the update belongs to, or nil
+func (c *Context) Chat() *Chat {
+	return c.Update.EffectiveChat()
+}
+
+// Sender returns the user that caused the update, or nil
+func (c *Context) Sender() *User {
+	return c.Update.EffectiveUser()
+}
+
+// Args returns the arguments of the command being handled, see Group.Command
+func (c *Context) Args() []string {
+	if command, ok := CommandFromContext(c.Context); ok {
+		return command.Args
+	}
+	return nil
+}
+
+// Send sends a message to the update's chat
+func (c *Context) Send(text string) (*Message, error) {
+	return c.SendAdvanced(text, nil)
+}
+
+// SendAdvanced sends a message to the update's chat with advanced options. Messages in a
+// forum topic are answered in the same topic unless MessageThreadID is set
+func (c *Context) SendAdvanced(text string, options *SendMessageOptions) (*Message, error) {
+	chat := c.Chat()
+	if chat == nil {
+		return nil, ErrNoChat
+	}
+
+	request := newSendMessageRequest(chat.ID, text, options)
+	if message := c.Message(); request.MessageThreadID == 0 && message != nil && message.IsTopicMessage {
+		request.MessageThreadID = message.MessageThreadID
+	}
+	return c.Bot.sendMessage(c, request)
+}
+
+// Reply sends a message replying to the update's message
+func (c *Context) Reply(text string) (*Message, error) {
+	return c.ReplyAdvanced(text, nil)
+}
+
+// ReplyAdvanced sends a message replying to the update's message with advanced options
+func (c *Context) ReplyAdvanced(text string, options *SendMessageOptions) (*Message, error) {
+	replyOptions := SendMessageOptions{}
+	if options != nil {
+		replyOptions = *options
+	}
+	if message := c.Message(); message != nil && replyOptions.ReplyToMessageID == 0 {
+		replyOptions.ReplyToMessageID = message.MessageID
+		replyOptions.AllowSendingWithoutReply = true
+	}
+	return c.SendAdvanced(text, &replyOptions)
+}
+
+// EditOrSend edits the message a callback query button belongs to, so menus update in place.
+// For other updates it sends a new message. Options may be nil
+func (c *Context) EditOrSend(text string, options *SendMessageOptions) error {
+	query := c.Update.CallbackQuery
+	if query == nil || (query.Message == nil && query.InlineMessageID == "") {
+		_, err := c.SendAdvanced(text, options)
+		return err
+	}
+
+	editOptions := &EditMessageTextOptions{Text: text, InlineMessageID: query.InlineMessageID}
+	if query.Message != nil {
+		editOptions.ChatID = query.Message.Chat.ID
+		editOptions.MessageID = query.Message.MessageID
+	}
+	if options != nil {
+		editOptions.ParseMode = options.ParseMode
+		editOptions.Entities = options.Entities
+		editOptions.DisableWebPagePreview = options.DisableWebPagePreview
+		editOptions.ReplyMarkup = options.ReplyMarkup
+	}
+	return c.Bot.EditMessageTextWithContext(c, editOptions)
+}
+
+// EditReplyMarkup replaces the inline keyboard of the message a callback query button belongs to.
+// Edits that would leave the message unchanged succeed without effect
+func (c *Context) EditReplyMarkup(markup *InlineKeyboardMarkup) error {
+	query := c.Update.CallbackQuery
+	if query == nil {
+		return ErrNotCallbackQuery
+	}
+
+	options := &EditMessageReplyMarkupOptions{InlineMessageID: query.InlineMessageID, ReplyMarkup: markup}
+	if query.Message != nil {
+		options.ChatID = query.Message.Chat.ID
+		options.MessageID = query.Message.MessageID
+	}
+	if err := c.Bot.EditMessageReplyMarkupWithContext(c, options); err != nil && !isMessageNotModified(err) {
+		return err
+	}
+	return nil
+}
+
+// Answer answers the callback query, showing text as a notification if it is not empty
+func (c *Context) Answer(text string) error {
+	return c.AnswerAdvanced(&AnswerCallbackQueryOptions{Text: text})
+}
+
+// AnswerAdvanced answers the callback query with advanced options, which may be nil. The query
+// ID is filled in. Over a webhook the answer is sent in the HTTP response when possible
+func (c *Context) AnswerAdvanced(options *AnswerCallbackQueryOptions) error {
+	if c.Update.CallbackQuery == nil {
+		return ErrNotCallbackQuery
+	}
+
+	var answerOptions AnswerCallbackQueryOptions
+	if options != nil {
+		answerOptions = *options
+	}
+	answerOptions.CallbackQueryID = c.Update.CallbackQuery.ID
+	c.answered.Store(true)
+	return c.Bot.RespondWithCallbackAnswer(c, c.Update, &answerOptions)
+}
+
+// Delete deletes the update's message
+func (c *Context) Delete() error {
+	message := c.Message()
+	if message == nil {
+		return ErrNoChat
+	}
+	return c.Bot.DeleteMessageWithContext(c, message.Chat.ID, message.MessageID)
+}
+
+// Conversation returns the conversation session of the update, or nil outside a conversation
+func (c *Context) Conversation() *ConversationSession {
+	session, _ := ConversationFromContext(c.Context)
+	return session
+}
+
+// T translates key with the translator of I18n.Middleware, see Translator.T. Without the
+// middleware it returns the key
+func (c *Context) T(key string, args ...interface{}) string {
+	return c.Translator().T(key, args...)
+}
+
+// Translator returns the translator of I18n.Middleware, or nil without the middleware
+func (c *Context) Translator() *Translator {
+	translator, _ := TranslatorFromContext(c.Context)
+	return translator
+}
@@ -0,0 +1,120 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContextReplyKeepsTopic(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.on("sendMessage", Message{MessageID: 100, Text: "pong"})
+
+	update := &Update{Message: &Message{
+		MessageID:       5,
+		MessageThreadID: 42,
+		IsTopicMessage:  true,
+		Chat:            Chat{ID: -100, Type: "supergroup"},
+	}}
+	c := NewContext(context.Background(), bot, update)
+
+	sent, err := c.Reply("pong")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sent.MessageID != 100 {
+		t.Errorf("Expected sent message 100, got %d", sent.MessageID)
+	}
+
+	params := api.callsTo("sendMessage")[0].Params
+	if params["chat_id"] != float64(-100) || params["reply_to_message_id"] != float64(5) || params["message_thread_id"] != float64(42) {
+		t.Errorf("Unexpected sendMessage parameters: %v", params)
+	}
+}
+
+func TestContextSendWithoutChat(t *testing.T) {
+	bot, _ := newTestBot(t)
+	c := NewContext(context.Background(), bot, &Update{InlineQuery: &InlineQuery{ID: "1"}})
+
+	if _, err := c.Send("hi"); !errors.Is(err, ErrNoChat) {
+		t.Errorf("Expected ErrNoChat, got %v", err)
+	}
+	if err := c.Answer("hi"); !errors.Is(err, ErrNotCallbackQuery) {
+		t.Errorf("Expected ErrNotCallbackQuery, got %v", err)
+	}
+}
+
+func TestContextEditOrSend(t *testing.T) {
+	bot, api := newTestBot(t)
+	update := &Update{CallbackQuery: &CallbackQuery{
+		ID:      "cb",
+		Message: &Message{MessageID: 7, Chat: Chat{ID: 1}},
+	}}
+	c := NewContext(context.Background(), bot, update)
+
+	if err := c.EditOrSend("page 2", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	params := api.callsTo("editMessageText")[0].Params
+	if params["chat_id"] != float64(1) || params["message_id"] != float64(7) || params["text"] != "page 2" {
+		t.Errorf("Unexpected editMessageText parameters: %v", params)
+	}
+}
+
+func TestHandleContextAutoAnswer(t *testing.T) {
+	bot, api := newTestBot(t)
+	ctx := ContextWithBot(context.Background(), bot)
+	update := &Update{CallbackQuery: &CallbackQuery{ID: "cb"}}
+
+	handler := HandleContext(func(c *Context) error { return nil })
+	if err := handler(ctx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	calls := api.callsTo("answerCallbackQuery")
+	if len(calls) != 1 || calls[0].Params["callback_query_id"] != "cb" {
+		t.Errorf("Expected callback query to be answered automatically, got %v", calls)
+	}
+
+	handler = HandleContext(func(c *Context) error { return c.Answer("done") })
+	if err := handler(ctx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	calls = api.callsTo("answerCallbackQuery")
+	if len(calls) != 2 || calls[1].Params["text"] != "done" {
+		t.Errorf("Expected a single answer from the handler, got %v", calls)
+	}
+}
+
+func TestContextAnswerAdvancedNilOptions(t *testing.T) {
+	bot, api := newTestBot(t)
+	ctx := ContextWithBot(context.Background(), bot)
+	update := &Update{CallbackQuery: &CallbackQuery{ID: "cb"}}
+
+	handler := HandleContext(func(c *Context) error { return c.AnswerAdvanced(nil) })
+	if err := handler(ctx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	calls := api.callsTo("answerCallbackQuery")
+	if len(calls) != 1 || calls[0].Params["callback_query_id"] != "cb" {
+		t.Errorf("Expected a single answer without options, got %v", calls)
+	}
+}
+
+func TestHandleContextArgs(t *testing.T) {
+	bot, _ := newTestBot(t)
+
+	var args []string
+	router := NewRouter()
+	router.SetUsername("OurBot")
+	router.Command("echo", HandleContext(func(c *Context) error {
+		args = c.Args()
+		return nil
+	}))
+
+	ctx := ContextWithBot(context.Background(), bot)
+	_ = router.HandleUpdate(ctx, &Update{Message: commandMessage(`/echo one "two three"`, 5)})
+
+	if len(args) != 2 || args[1] != "two three" {
+		t.Errorf("Unexpected args %q", args)
+	}
+}
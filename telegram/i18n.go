@@ -0,0 +1,522 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// I18nOptions configures an I18n
+type I18nOptions struct {
+	DefaultLanguage string                // Last language of every fallback chain, "en" by default
+	Fallbacks       map[string][]string   // Languages tried before the default, e.g. "uk": {"ru"}
+	PluralRules     map[string]PluralRule // Adds or replaces plural rules, keyed by language
+
+	// Language returns the language chosen by the user, e.g. stored in a Session. When it is
+	// nil or returns an empty string, the middleware uses the sender's User.LanguageCode
+	Language func(ctx context.Context, u *Update) string
+}
+
+// I18n holds message catalogs for several languages
+type I18n struct {
+	options  I18nOptions
+	catalogs map[string]map[string]catalogMessage
+}
+
+// catalogMessage is a translated message, either plain text or plural forms keyed by category
+type catalogMessage struct {
+	text  string
+	forms map[string]string
+}
+
+// LoadI18n loads the .json and .toml catalogs in fsys. Each file is named after its language,
+// such as de.json or pt-BR.toml. Nested tables become dotted keys, and a table whose keys are
+// plural categories (one, few, many, other, ...) holds the plural forms of one message.
+// Options may be nil
+func LoadI18n(fsys fs.FS, options *I18nOptions) (*I18n, error) {
+	i := &I18n{catalogs: make(map[string]map[string]catalogMessage)}
+	if options != nil {
+		i.options = *options
+	}
+	if i.options.DefaultLanguage == "" {
+		i.options.DefaultLanguage = "en"
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		ext := path.Ext(name)
+		if ext != ".json" && ext != ".toml" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var values map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &values)
+		} else {
+			values, err = parseTOML(string(data))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		language := normalizeLanguage(strings.TrimSuffix(path.Base(name), ext))
+		if i.catalogs[language] == nil {
+			i.catalogs[language] = make(map[string]catalogMessage)
+		}
+		if err := flattenCatalog(i.catalogs[language], "", values); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalogs: %w", err)
+	}
+	return i, nil
+}
+
+// flattenCatalog adds the messages in values to catalog under dotted keys
+func flattenCatalog(catalog map[string]catalogMessage, prefix string, values map[string]interface{}) error {
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			catalog[prefix+key] = catalogMessage{text: v}
+		case map[string]interface{}:
+			if forms, ok := pluralForms(v); ok {
+				catalog[prefix+key] = catalogMessage{forms: forms}
+			} else if err := flattenCatalog(catalog, prefix+key+".", v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %q is not a string or a table", prefix+key)
+		}
+	}
+	return nil
+}
+
+// pluralForms reports whether a table holds plural forms: string values keyed by plural
+// categories, including other
+func pluralForms(table map[string]interface{}) (map[string]string, bool) {
+	if _, ok := table[PluralOther]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(table))
+	for category, value := range table {
+		text, ok := value.(string)
+		switch category {
+		case PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther:
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, false
+		}
+		forms[category] = text
+	}
+	return forms, true
+}
+
+// Languages returns the languages with a catalog, sorted
+func (i *I18n) Languages() []string {
+	languages := make([]string, 0, len(i.catalogs))
+	for language := range i.catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Translator returns a translator for a language such as "de" or "pt-BR". Messages missing in
+// the language are looked up in its base language, its fallbacks and the default language
+func (i *I18n) Translator(language string) *Translator {
+	var chain []string
+	add := func(languages ...string) {
+		for _, language := range languages {
+			language = normalizeLanguage(language)
+			if language != "" && !slices.Contains(chain, language) {
+				chain = append(chain, language)
+			}
+		}
+	}
+
+	tag := normalizeLanguage(language)
+	base, _, _ := strings.Cut(tag, "-")
+	add(tag, base)
+	add(i.options.Fallbacks[tag]...)
+	add(i.options.Fallbacks[base]...)
+	add(i.options.DefaultLanguage)
+	return &Translator{i18n: i, chain: chain}
+}
+
+// pluralCategory returns the plural category of count, preferring the configured rules
+func (i *I18n) pluralCategory(language string, count int64) string {
+	base, _, _ := strings.Cut(language, "-")
+	for _, key := range []string{language, base} {
+		for custom, rule := range i.options.PluralRules {
+			if normalizeLanguage(custom) == key {
+				if count < 0 {
+					count = -count
+				}
+				return rule(count)
+			}
+		}
+	}
+	return PluralCategory(language, count)
+}
+
+type translatorKey struct{}
+
+// Middleware returns the middleware adding a translator for the update's user to the handler
+// context, see TranslatorFromContext. Run it after the session middleware Language reads from
+func (i *I18n) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) error {
+			translator := i.Translator(i.language(ctx, u))
+			return next.HandleUpdate(context.WithValue(ctx, translatorKey{}, translator), u)
+		})
+	}
+}
+
+// language returns the language for an update: the user's choice, then their client language
+func (i *I18n) language(ctx context.Context, u *Update) string {
+	if i.options.Language != nil {
+		if language := i.options.Language(ctx, u); language != "" {
+			return language
+		}
+	}
+	if user := u.EffectiveUser(); user != nil {
+		return user.LanguageCode
+	}
+	return ""
+}
+
+// TranslatorFromContext returns the translator added by I18n.Middleware
+func TranslatorFromContext(ctx context.Context) (*Translator, bool) {
+	translator, ok := ctx.Value(translatorKey{}).(*Translator)
+	return translator, ok
+}
+
+// SetMyCommands sets the command list in every loaded language with a two-letter code, and in
+// the default language for all other users. The command descriptions are catalog keys
+func (i *I18n) SetMyCommands(ctx context.Context, bot *Bot, commands []BotCommand, scope *BotCommandScope) error {
+	for _, language := range append([]string{""}, i.Languages()...) {
+		if language != "" && len(language) != 2 {
+			continue
+		}
+
+		translator := i.Translator(language)
+		if language == "" {
+			translator = i.Translator(i.options.DefaultLanguage)
+		}
+		localized := make([]BotCommand, len(commands))
+		for n, command := range commands {
+			localized[n] = BotCommand{Command: command.Command, Description: translator.T(command.Description)}
+		}
+
+		options := &SetMyCommandsOptions{Scope: scope, LanguageCode: language}
+		if err := bot.SetMyCommandsAdvancedWithContext(ctx, localized, options); err != nil {
+			return fmt.Errorf("failed to set commands for language %q: %w", language, err)
+		}
+	}
+	return nil
+}
+
+// Translator looks up messages for one language. A nil translator returns keys unchanged
+type Translator struct {
+	i18n  *I18n
+	chain []string
+}
+
+// Language returns the first language of the fallback chain that has a catalog
+func (t *Translator) Language() string {
+	if t == nil {
+		return ""
+	}
+	for _, language := range t.chain {
+		if _, ok := t.i18n.catalogs[language]; ok {
+			return language
+		}
+	}
+	return t.chain[len(t.chain)-1]
+}
+
+// T returns the message for key with {name} placeholders replaced by args, given as name and
+// value pairs. Missing messages return the key itself
+func (t *Translator) T(key string, args ...interface{}) string {
+	message, _, ok := t.lookup(key)
+	if !ok {
+		return key
+	}
+	text := message.text
+	if message.forms != nil {
+		text = message.forms[PluralOther]
+	}
+	return interpolate(text, args)
+}
+
+// Plural returns the plural form of the message for key that matches count. The {count}
+// placeholder holds the count, other placeholders are replaced by args as in T
+func (t *Translator) Plural(key string, count int, args ...interface{}) string {
+	message, language, ok := t.lookup(key)
+	if !ok {
+		return key
+	}
+	text := message.text
+	if message.forms != nil {
+		var found bool
+		if text, found = message.forms[t.i18n.pluralCategory(language, int64(count))]; !found {
+			text = message.forms[PluralOther]
+		}
+	}
+	return interpolate(text, append([]interface{}{"count", count}, args...))
+}
+
+// lookup finds the message for key along the fallback chain
+func (t *Translator) lookup(key string) (catalogMessage, string, bool) {
+	if t == nil {
+		return catalogMessage{}, "", false
+	}
+	for _, language := range t.chain {
+		if message, ok := t.i18n.catalogs[language][key]; ok {
+			return message, language, true
+		}
+	}
+	return catalogMessage{}, "", false
+}
+
+// interpolate replaces {name} placeholders with the values of name and value pairs
+func interpolate(text string, args []interface{}) string {
+	if len(args) < 2 {
+		return text
+	}
+	pairs := make([]string, 0, len(args))
+	for n := 0; n+1 < len(args); n += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[n])+"}", fmt.Sprint(args[n+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// parseTOML parses the subset of TOML catalogs use: comments, [tables] and keys with basic,
+// literal or multi-line string values. Keys may be dotted and quoted; anything else, such as
+// arrays, inline tables or non-string values, is an error
+func parseTOML(data string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: arrays of tables are not supported", n+1)
+			}
+			keys, rest, err := parseTOMLKey(line[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if rest = strings.TrimSpace(rest); !strings.HasPrefix(rest, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header", n+1)
+			}
+			if rest = strings.TrimSpace(rest[1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %q after table header", n+1, rest)
+			}
+			if table, err = tomlTable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			continue
+		}
+
+		keys, rest, err := parseTOMLKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+		rawValue := strings.TrimSpace(rest[1:])
+
+		// Multi-line strings continue until their closing delimiter
+		for _, delimiter := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(rawValue, delimiter) {
+				for strings.Count(rawValue, delimiter) < 2 && n+1 < len(lines) {
+					n++
+					rawValue += "\n" + lines[n]
+				}
+			}
+		}
+		value, err := parseTOMLString(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		parent, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+	return root, nil
+}
+
+// parseTOMLKey parses a dotted key of bare, basic and literal parts at the start of s and
+// returns the parts and the text after the key
+func parseTOMLKey(s string) ([]string, string, error) {
+	var keys []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case strings.HasPrefix(s, `"`):
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			unescaped, err := unescapeTOML(s[1:end])
+			if err != nil {
+				return nil, "", err
+			}
+			part, s = unescaped, s[end+1:]
+		case strings.HasPrefix(s, "'"):
+			end := strings.Index(s[1:], "'")
+			if end < 0 {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			part, s = s[1:1+end], s[2+end:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, "", fmt.Errorf("invalid key at %q", s)
+			}
+			part, s = s[:end], s[end:]
+		}
+		keys = append(keys, part)
+
+		if s = strings.TrimLeft(s, " \t"); !strings.HasPrefix(s, ".") {
+			return keys, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// tomlTable returns the nested table at keys, creating missing tables
+func tomlTable(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	table := root
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case map[string]interface{}:
+			table = next
+		case nil:
+			created := make(map[string]interface{})
+			table[key] = created
+			table = created
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// parseTOMLString parses a string value followed by an optional comment
+func parseTOMLString(value string) (string, error) {
+	var text, rest string
+	switch {
+	case strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, `'''`):
+		delimiter := value[:3]
+		end := 3
+		for end < len(value) && !strings.HasPrefix(value[end:], delimiter) {
+			if delimiter == `"""` && value[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(value) {
+			return "", fmt.Errorf("unterminated multi-line string")
+		}
+		// Up to two quotes may directly precede the closing delimiter
+		for n := 0; n < 2 && strings.HasPrefix(value[end+1:], delimiter); n++ {
+			end++
+		}
+		text, rest = strings.TrimPrefix(value[3:end], "\n"), value[end+3:]
+		if delimiter == `"""` {
+			unescaped, err := unescapeTOML(text)
+			if err != nil {
+				return "", err
+			}
+			text = unescaped
+		}
+	case strings.HasPrefix(value, `"`):
+		end := 1
+		for end < len(value) && value[end] != '"' {
+			if value[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(value) {
+			return "", fmt.Errorf("unterminated string")
+		}
+		unescaped, err := unescapeTOML(value[1:end])
+		if err != nil {
+			return "", err
+		}
+		text, rest = unescaped, value[end+1:]
+	case strings.HasPrefix(value, `'`):
+		end := strings.Index(value[1:], `'`)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		text, rest = value[1:1+end], value[2+end:]
+	default:
+		return "", fmt.Errorf("values must be strings")
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after value", rest)
+	}
+	return text, nil
+}
+
+// unescapeTOML resolves the backslash escapes of a basic string
+func unescapeTOML(s string) (string, error) {
+	var out strings.Builder
+	for s != "" {
+		if s[0] == '"' {
+			out.WriteByte('"')
+			s = s[1:]
+			continue
+		}
+		r, _, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in string")
+		}
+		out.WriteRune(r)
+		s = tail
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,215 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testCatalogs = fstest.MapFS{
+	"locales/en.json": {Data: []byte(`{
+		"hello": "Hello, {name}!",
+		"files": {"one": "{count} file", "other": "{count} files"},
+		"menu": {"settings": "Settings", "help": "Help"},
+		"commands": {"start": "Start the bot"}
+	}`)},
+	"locales/ru.toml": {Data: []byte(`# Russian
+hello = "Привет, {name}!" # greeting
+'quoted key' = 'literal \n'
+
+[files]
+one = "{count} файл"
+few = "{count} файла"
+many = "{count} файлов"
+other = "{count} файла"
+
+[menu]
+settings = """
+Настройки\t"ок\""""
+
+[commands]
+start = "Запустить бота"
+`)},
+	"locales/pt-BR.json": {Data: []byte(`{"hello": "Olá, {name}!"}`)},
+	"README.md":          {Data: []byte("ignored")},
+}
+
+func loadTestI18n(t *testing.T, options *I18nOptions) *I18n {
+	i18n, err := LoadI18n(testCatalogs, options)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return i18n
+}
+
+func TestI18nTranslate(t *testing.T) {
+	i18n := loadTestI18n(t, &I18nOptions{Fallbacks: map[string][]string{"uk": {"ru"}}})
+
+	if languages := i18n.Languages(); len(languages) != 3 || languages[0] != "en" || languages[1] != "pt-br" {
+		t.Errorf("Unexpected languages: %v", languages)
+	}
+
+	ru := i18n.Translator("ru")
+	if got := ru.T("hello", "name", "Аня"); got != "Привет, Аня!" {
+		t.Errorf("Unexpected greeting: %q", got)
+	}
+	if got := ru.T("quoted key"); got != `literal \n` {
+		t.Errorf("Unexpected literal string: %q", got)
+	}
+	if got := ru.T("menu.settings"); got != "Настройки\t\"ок\"" {
+		t.Errorf("Unexpected multi-line string: %q", got)
+	}
+	if got := ru.T("menu.help"); got != "Help" {
+		t.Errorf("Expected fallback to the default language, got %q", got)
+	}
+	if got := ru.T("missing.key"); got != "missing.key" {
+		t.Errorf("Expected the key for missing messages, got %q", got)
+	}
+
+	for count, expected := range map[int]string{1: "1 файл", 3: "3 файла", 5: "5 файлов", 21: "21 файл"} {
+		if got := ru.Plural("files", count); got != expected {
+			t.Errorf("Plural(%d): expected %q, got %q", count, expected, got)
+		}
+	}
+	if got := i18n.Translator("en").Plural("files", 1); got != "1 file" {
+		t.Errorf("Unexpected English plural: %q", got)
+	}
+
+	// Regional tags fall back to their base language, then to configured fallbacks
+	if got := i18n.Translator("pt-BR").T("hello", "name", "Ana"); got != "Olá, Ana!" {
+		t.Errorf("Unexpected Portuguese greeting: %q", got)
+	}
+	uk := i18n.Translator("uk-UA")
+	if uk.Language() != "ru" || uk.Plural("files", 2) != "2 файла" {
+		t.Errorf("Expected Ukrainian to fall back to Russian, got %s", uk.Language())
+	}
+	if got := i18n.Translator("").Language(); got != "en" {
+		t.Errorf("Expected the default language, got %q", got)
+	}
+
+	var none *Translator
+	if none.T("hello") != "hello" || none.Language() != "" {
+		t.Error("Expected a nil translator to return keys")
+	}
+}
+
+func TestI18nPluralRules(t *testing.T) {
+	i18n := loadTestI18n(t, &I18nOptions{PluralRules: map[string]PluralRule{
+		"en": func(n int64) string { return PluralOther },
+	}})
+	if got := i18n.Translator("en-GB").Plural("files", 1); got != "1 files" {
+		t.Errorf("Expected the configured rule, got %q", got)
+	}
+}
+
+func TestI18nLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad.json":  `{"a": 1}`,
+		"bad.toml":  `a = 1`,
+		"open.toml": `a = "unterminated`,
+		"tail.toml": `a = "x" y`,
+		"head.toml": `[a`,
+		"key.toml":  `a b = "x"`,
+		"arr.toml":  `[[a]]`,
+		"junk.toml": `[a] b`,
+	}
+	for name, data := range tests {
+		if _, err := LoadI18n(fstest.MapFS{name: {Data: []byte(data)}}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseTOMLQuotedKeys(t *testing.T) {
+	values, err := parseTOML(`"a.b" = "x"
+"a=b" = "y"
+site . "example.com" = 'z'
+
+[ "t.x" . y ]
+'k' = "v"
+`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string]interface{}{
+		"a.b":  "x",
+		"a=b":  "y",
+		"site": map[string]interface{}{"example.com": "z"},
+		"t.x":  map[string]interface{}{"y": map[string]interface{}{"k": "v"}},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestI18nMiddleware(t *testing.T) {
+	type Settings struct{ Language string }
+	settings := NewSession[Settings](nil)
+	i18n := loadTestI18n(t, &I18nOptions{Language: func(ctx context.Context, u *Update) string {
+		if s := settings.Get(ctx); s != nil {
+			return s.Language
+		}
+		return ""
+	}})
+
+	var greetings []string
+	handler := Chain(settings.Middleware(), i18n.Middleware())(HandlerFunc(func(ctx context.Context, u *Update) error {
+		c := NewContext(ctx, nil, u)
+		greetings = append(greetings, c.T("hello", "name", u.Message.From.FirstName))
+		if u.Message.Text == "/en" {
+			settings.Get(ctx).Language = "en"
+		}
+		return nil
+	}))
+
+	update := func(text string) *Update {
+		return &Update{Message: &Message{Text: text, Chat: Chat{ID: 1}, From: &User{ID: 2, FirstName: "Ann", LanguageCode: "ru"}}}
+	}
+	for _, text := range []string{"hi", "/en", "hi"} {
+		if err := handler.HandleUpdate(context.Background(), update(text)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if greetings[0] != "Привет, Ann!" || greetings[2] != "Hello, Ann!" {
+		t.Errorf("Expected the session language to override the client language, got %v", greetings)
+	}
+
+	if c := NewContext(context.Background(), nil, update("hi")); c.T("hello") != "hello" || c.Translator() != nil {
+		t.Error("Expected keys without the middleware")
+	}
+}
+
+func TestI18nSetMyCommands(t *testing.T) {
+	bot, api := newTestBot(t)
+	i18n := loadTestI18n(t, nil)
+
+	commands := []BotCommand{{Command: "start", Description: "commands.start"}}
+	if err := i18n.SetMyCommands(context.Background(), bot, commands, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	descriptions := map[interface{}]interface{}{}
+	for _, call := range api.callsTo("setMyCommands") {
+		command := call.Params["commands"].([]interface{})[0].(map[string]interface{})
+		descriptions[call.Params["language_code"]] = command["description"]
+	}
+	expected := map[interface{}]interface{}{nil: "Start the bot", "en": "Start the bot", "ru": "Запустить бота"}
+	if len(descriptions) != len(expected) {
+		t.Errorf("Expected commands for the default, en and ru, got %v", descriptions)
+	}
+	for language, description := range expected {
+		if descriptions[language] != description {
+			t.Errorf("Language %v: expected %q, got %v", language, description, descriptions[language])
+		}
+	}
+
+	api.handle("setMyCommands", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		return nil, &APIResponse{ErrorCode: 400, Description: "Bad Request"}
+	})
+	var httpErr *HTTPError
+	if err := i18n.SetMyCommands(context.Background(), bot, commands, nil); !errors.As(err, &httpErr) {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
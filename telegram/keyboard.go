@@ -0,0 +1,184 @@
+package gotele
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Inline keyboard limits checked by InlineKeyboardBuilder.Build
+const (
+	MaxInlineKeyboardButtons    = 100 // Buttons in one keyboard
+	MaxInlineKeyboardRowButtons = 8   // Buttons in one row
+	MaxInlineButtonTextLength   = 64  // Characters of button text
+	MaxCopyTextLength           = 256 // Characters copied by a copy text button
+)
+
+// InlineKeyboardBuilder builds an InlineKeyboardMarkup button by button. Buttons are added to the
+// current row, which wraps automatically once it holds the configured number of columns.
+// Mistakes are reported by Build
+type InlineKeyboardBuilder struct {
+	rows    [][]InlineKeyboardButton
+	columns int
+	err     error
+}
+
+// NewInlineKeyboard creates an empty inline keyboard builder
+func NewInlineKeyboard() *InlineKeyboardBuilder {
+	return &InlineKeyboardBuilder{}
+}
+
+// Columns wraps rows after n buttons. 0 disables wrapping, leaving rows to Row
+func (kb *InlineKeyboardBuilder) Columns(n int) *InlineKeyboardBuilder {
+	kb.columns = n
+	return kb
+}
+
+// Row starts a new row. Empty rows are not created
+func (kb *InlineKeyboardBuilder) Row() *InlineKeyboardBuilder {
+	if len(kb.rows) > 0 && len(kb.rows[len(kb.rows)-1]) > 0 {
+		kb.rows = append(kb.rows, nil)
+	}
+	return kb
+}
+
+// Button adds a button to the current row
+func (kb *InlineKeyboardBuilder) Button(button InlineKeyboardButton) *InlineKeyboardBuilder {
+	last := len(kb.rows) - 1
+	if last < 0 || (kb.columns > 0 && len(kb.rows[last]) >= kb.columns) {
+		kb.rows = append(kb.rows, nil)
+		last++
+	}
+	kb.rows[last] = append(kb.rows[last], button)
+	return kb
+}
+
+// Callback adds a button sending a callback query with data
+func (kb *InlineKeyboardBuilder) Callback(text, data string) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, CallbackData: data})
+}
+
+// URL adds a button opening url
+func (kb *InlineKeyboardBuilder) URL(text, url string) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, URL: url})
+}
+
+// LoginURL adds a button authorizing the user on a website
+func (kb *InlineKeyboardBuilder) LoginURL(text string, loginURL LoginURL) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, LoginURL: &loginURL})
+}
+
+// WebApp adds a button launching the Web App at url
+func (kb *InlineKeyboardBuilder) WebApp(text, url string) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, WebApp: &WebApp{URL: url}})
+}
+
+// SwitchInline adds a button letting the user pick a chat and starting an inline query there.
+// The query must not be empty, as an empty switch_inline_query is dropped from the request
+func (kb *InlineKeyboardBuilder) SwitchInline(text, query string) *InlineKeyboardBuilder {
+	kb.requireQuery(text, query)
+	return kb.Button(InlineKeyboardButton{Text: text, SwitchInlineQuery: query})
+}
+
+// SwitchInlineCurrentChat adds a button starting an inline query in the current chat.
+// The query must not be empty, as an empty switch_inline_query_current_chat is dropped from the request
+func (kb *InlineKeyboardBuilder) SwitchInlineCurrentChat(text, query string) *InlineKeyboardBuilder {
+	kb.requireQuery(text, query)
+	return kb.Button(InlineKeyboardButton{Text: text, SwitchInlineQueryCurrentChat: query})
+}
+
+// requireQuery records an error for a switch inline button without a query, reported by Build
+func (kb *InlineKeyboardBuilder) requireQuery(text, query string) {
+	if query == "" && kb.err == nil {
+		kb.err = fmt.Errorf("%w: switch inline button %q has an empty query", ErrInvalidKeyboard, text)
+	}
+}
+
+// SwitchInlineChosenChat adds a button starting an inline query in a chat of the chosen type
+func (kb *InlineKeyboardBuilder) SwitchInlineChosenChat(text string, chosen SwitchInlineQueryChosenChat) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, SwitchInlineQueryChosenChat: &chosen})
+}
+
+// Pay adds a pay button. It must be the first button of an invoice keyboard
+func (kb *InlineKeyboardBuilder) Pay(text string) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, Pay: true})
+}
+
+// CopyText adds a button copying text to the clipboard
+func (kb *InlineKeyboardBuilder) CopyText(text, copyText string) *InlineKeyboardBuilder {
+	return kb.Button(InlineKeyboardButton{Text: text, CopyText: &CopyTextButton{Text: copyText}})
+}
+
+// Build validates the keyboard against Telegram's limits and returns it. Errors match
+// ErrInvalidKeyboard and name the offending button
+func (kb *InlineKeyboardBuilder) Build() (*InlineKeyboardMarkup, error) {
+	if kb.err != nil {
+		return nil, kb.err
+	}
+
+	rows := make([][]InlineKeyboardButton, 0, len(kb.rows))
+	total := 0
+	for _, row := range kb.rows {
+		if len(row) == 0 {
+			continue
+		}
+		if len(row) > MaxInlineKeyboardRowButtons {
+			return nil, fmt.Errorf("%w: row %d has %d buttons, at most %d are allowed", ErrInvalidKeyboard, len(rows)+1, len(row), MaxInlineKeyboardRowButtons)
+		}
+		for i, button := range row {
+			if err := validateInlineButton(button, total == 0 && i == 0); err != nil {
+				return nil, fmt.Errorf("%w: row %d, button %d: %v", ErrInvalidKeyboard, len(rows)+1, i+1, err)
+			}
+		}
+		total += len(row)
+		rows = append(rows, row)
+	}
+	if total > MaxInlineKeyboardButtons {
+		return nil, fmt.Errorf("%w: %d buttons, at most %d are allowed", ErrInvalidKeyboard, total, MaxInlineKeyboardButtons)
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// validateInlineButton checks a single button. First reports whether it is the first button of the keyboard
+func validateInlineButton(button InlineKeyboardButton, first bool) error {
+	textLength := utf8.RuneCountInString(button.Text)
+	if textLength == 0 {
+		return fmt.Errorf("text is empty")
+	}
+	if textLength > MaxInlineButtonTextLength {
+		return fmt.Errorf("text %q has %d characters, at most %d are allowed", button.Text, textLength, MaxInlineButtonTextLength)
+	}
+
+	actions := 0
+	for _, set := range []bool{
+		button.URL != "",
+		button.LoginURL != nil,
+		button.CallbackData != "",
+		button.SwitchInlineQuery != "",
+		button.SwitchInlineQueryCurrentChat != "",
+		button.SwitchInlineQueryChosenChat != nil,
+		button.CallbackGame != nil,
+		button.Pay,
+		button.WebApp != nil,
+		button.CopyText != nil,
+	} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return fmt.Errorf("button %q must have exactly one action, has %d", button.Text, actions)
+	}
+
+	if len(button.CallbackData) > MaxCallbackDataBytes {
+		return fmt.Errorf("callback data of %q has %d bytes, at most %d are allowed", button.Text, len(button.CallbackData), MaxCallbackDataBytes)
+	}
+	if button.CopyText != nil {
+		if length := utf8.RuneCountInString(button.CopyText.Text); length == 0 || length > MaxCopyTextLength {
+			return fmt.Errorf("copy text of %q must have 1-%d characters", button.Text, MaxCopyTextLength)
+		}
+	}
+	if (button.Pay || button.CallbackGame != nil) && !first {
+		return fmt.Errorf("pay and game buttons must be the first button")
+	}
+	return nil
+}
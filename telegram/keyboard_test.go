@@ -0,0 +1,72 @@
+package gotele
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInlineKeyboardBuilder(t *testing.T) {
+	keyboard, err := NewInlineKeyboard().
+		Columns(2).
+		Callback("1", "one").
+		Callback("2", "two").
+		Callback("3", "three").
+		Row().
+		URL("Docs", "https://core.telegram.org/bots/api").
+		WebApp("App", "https://example.com/app").
+		CopyText("Copy", "code").
+		SwitchInlineChosenChat("Share", SwitchInlineQueryChosenChat{Query: "q", AllowUserChats: true}).
+		Build()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rows := keyboard.InlineKeyboard
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(rows))
+	}
+	if len(rows[0]) != 2 || len(rows[1]) != 1 || len(rows[2]) != 2 || len(rows[3]) != 2 {
+		t.Errorf("Unexpected row layout: %v", rows)
+	}
+	if rows[1][0].CallbackData != "three" || rows[2][1].WebApp.URL != "https://example.com/app" {
+		t.Errorf("Unexpected buttons: %v", rows)
+	}
+	if rows[3][0].CopyText.Text != "code" || !rows[3][1].SwitchInlineQueryChosenChat.AllowUserChats {
+		t.Errorf("Unexpected buttons: %v", rows[3])
+	}
+}
+
+func TestInlineKeyboardBuilderValidation(t *testing.T) {
+	tests := map[string]*InlineKeyboardBuilder{
+		"empty text":         NewInlineKeyboard().Callback("", "data"),
+		"long callback data": NewInlineKeyboard().Callback("Go", strings.Repeat("x", 65)),
+		"long text":          NewInlineKeyboard().Callback(strings.Repeat("x", 65), "data"),
+		"no action":          NewInlineKeyboard().Button(InlineKeyboardButton{Text: "Nothing"}),
+		"two actions":        NewInlineKeyboard().Button(InlineKeyboardButton{Text: "Both", URL: "https://example.com", CallbackData: "x"}),
+		"pay not first":      NewInlineKeyboard().Callback("Go", "go").Pay("Pay"),
+		"empty copy text":    NewInlineKeyboard().CopyText("Copy", ""),
+		"empty inline query": NewInlineKeyboard().SwitchInline("Share", ""),
+		"empty chat query":   NewInlineKeyboard().SwitchInlineCurrentChat("Search", ""),
+	}
+	wide := NewInlineKeyboard()
+	for i := 0; i < MaxInlineKeyboardRowButtons+1; i++ {
+		wide.Callback("x", "x")
+	}
+	tests["wide row"] = wide
+	large := NewInlineKeyboard().Columns(5)
+	for i := 0; i < MaxInlineKeyboardButtons+1; i++ {
+		large.Callback("x", "x")
+	}
+	tests["too many buttons"] = large
+
+	for name, builder := range tests {
+		if _, err := builder.Build(); !errors.Is(err, ErrInvalidKeyboard) {
+			t.Errorf("%s: expected ErrInvalidKeyboard, got %v", name, err)
+		}
+	}
+
+	if _, err := NewInlineKeyboard().Pay("Pay 5 EUR").Callback("Cancel", "cancel").Build(); err != nil {
+		t.Errorf("Expected leading pay button to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package gotele
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMenuDeniedText is shown when a user opens a menu node they may not access
+const DefaultMenuDeniedText = "Not available"
+
+// Menu operations encoded in callback data after the menu ID
+const (
+	menuOpen   = 'o'
+	menuToggle = 't'
+	menuAction = 'a'
+)
+
+type menuNodeKind int
+
+const (
+	menuSubmenu menuNodeKind = iota
+	menuToggleNode
+	menuActionNode
+)
+
+// MenuNode is a submenu, toggle or action of a Menu
+type MenuNode struct {
+	id        string
+	kind      menuNodeKind
+	label     string
+	labelFunc func(c *Context) string
+	text      string
+	textFunc  func(c *Context) string
+	allow     func(c *Context) bool
+	columns   int
+	children  []*MenuNode
+
+	get    func(c *Context) bool
+	set    func(c *Context, on bool) error
+	action func(c *Context) error
+}
+
+// Menu is a tree of inline menus. Opening a submenu edits the message in place and adds a
+// "‹ Back" button leading to the parent. The path to the open node is kept in the callback data,
+// so node IDs should be short to stay within 64 bytes
+type Menu struct {
+	*MenuNode
+
+	// DeniedText is shown as an alert when a user opens a node they may not access
+	DeniedText string
+
+	id string
+}
+
+// NewMenu creates a menu whose root shows text. The ID distinguishes menus in callback data.
+// Menu and node IDs must be non-empty and free of "/" and ":", otherwise the constructors panic
+func NewMenu(id, text string) *Menu {
+	mustMenuID(id)
+	return &Menu{
+		MenuNode:   &MenuNode{text: text, columns: 1},
+		DeniedText: DefaultMenuDeniedText,
+		id:         id,
+	}
+}
+
+// Submenu adds a submenu opened by a button labelled label and showing text
+func (n *MenuNode) Submenu(id, label, text string) *MenuNode {
+	return n.add(&MenuNode{id: id, kind: menuSubmenu, label: label, text: text, columns: 1})
+}
+
+// Toggle adds a button switching a setting on and off. Get reads the setting and set stores it
+func (n *MenuNode) Toggle(id, label string, get func(c *Context) bool, set func(c *Context, on bool) error) *MenuNode {
+	return n.add(&MenuNode{id: id, kind: menuToggleNode, label: label, get: get, set: set})
+}
+
+// Action adds a button running handler
+func (n *MenuNode) Action(id, label string, handler func(c *Context) error) *MenuNode {
+	return n.add(&MenuNode{id: id, kind: menuActionNode, label: label, action: handler})
+}
+
+// WithLabel computes the node's button label for every rendering
+func (n *MenuNode) WithLabel(label func(c *Context) string) *MenuNode {
+	n.labelFunc = label
+	return n
+}
+
+// WithText computes the text shown with a submenu for every rendering
+func (n *MenuNode) WithText(text func(c *Context) string) *MenuNode {
+	n.textFunc = text
+	return n
+}
+
+// WithAccess restricts the node. Nodes the user may not access are hidden and refuse to open
+func (n *MenuNode) WithAccess(allow func(c *Context) bool) *MenuNode {
+	n.allow = allow
+	return n
+}
+
+// Columns sets how many buttons of a submenu share a row
+func (n *MenuNode) Columns(columns int) *MenuNode {
+	n.columns = columns
+	return n
+}
+
+func (n *MenuNode) add(child *MenuNode) *MenuNode {
+	mustMenuID(child.id)
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *MenuNode) child(id string) *MenuNode {
+	for _, child := range n.children {
+		if child.id == id {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *MenuNode) allowed(c *Context) bool {
+	return n.allow == nil || n.allow(c)
+}
+
+func (n *MenuNode) buttonLabel(c *Context) string {
+	label := n.label
+	if n.labelFunc != nil {
+		label = n.labelFunc(c)
+	}
+	if n.kind == menuToggleNode {
+		if n.get(c) {
+			return "✅ " + label
+		}
+		return "⬜ " + label
+	}
+	return label
+}
+
+func (n *MenuNode) messageText(c *Context) string {
+	if n.textFunc != nil {
+		return n.textFunc(c)
+	}
+	return n.text
+}
+
+// Open shows the root of the menu, editing the message for callback queries and sending
+// a new message otherwise
+func (m *Menu) Open(c *Context) error {
+	return m.show(c, nil)
+}
+
+// Register handles the menu's callbacks in the group
+func (m *Menu) Register(g *Group) {
+	prefix := m.callbackPrefix()
+	g.CallbackQuery(HandleContext(m.handle), func(u *Update) bool {
+		return strings.HasPrefix(u.CallbackQuery.Data, prefix)
+	})
+}
+
+// handle runs a menu callback
+func (m *Menu) handle(c *Context) error {
+	data := strings.TrimPrefix(c.Update.CallbackQuery.Data, m.callbackPrefix())
+	if data == "" {
+		return nil
+	}
+	op, path := data[0], splitMenuPath(data[1:])
+
+	node, ok := m.resolve(c, path)
+	if !ok {
+		return c.AnswerAdvanced(&AnswerCallbackQueryOptions{Text: m.DeniedText, ShowAlert: true})
+	}
+
+	switch {
+	case op == menuOpen && node.kind == menuSubmenu:
+		return m.show(c, path)
+	case op == menuToggle && node.kind == menuToggleNode:
+		if err := node.set(c, !node.get(c)); err != nil {
+			return err
+		}
+		markup, err := m.keyboard(c, path[:len(path)-1])
+		if err != nil {
+			return err
+		}
+		return c.EditReplyMarkup(markup)
+	case op == menuAction && node.kind == menuActionNode:
+		return node.action(c)
+	}
+	return nil
+}
+
+// resolve walks path from the root, checking access on every level
+func (m *Menu) resolve(c *Context, path []string) (*MenuNode, bool) {
+	node := m.MenuNode
+	if !node.allowed(c) {
+		return nil, false
+	}
+	for _, id := range path {
+		if node = node.child(id); node == nil || !node.allowed(c) {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// show renders the submenu at path
+func (m *Menu) show(c *Context, path []string) error {
+	node, ok := m.resolve(c, path)
+	if !ok {
+		return fmt.Errorf("menu %s has no accessible node %q", m.id, strings.Join(path, "/"))
+	}
+	markup, err := m.keyboard(c, path)
+	if err != nil {
+		return err
+	}
+	return c.EditOrSend(node.messageText(c), &SendMessageOptions{ReplyMarkup: markup})
+}
+
+// keyboard renders the buttons of the submenu at path
+func (m *Menu) keyboard(c *Context, path []string) (*InlineKeyboardMarkup, error) {
+	node, ok := m.resolve(c, path)
+	if !ok {
+		return nil, fmt.Errorf("menu %s has no accessible node %q", m.id, strings.Join(path, "/"))
+	}
+
+	kb := NewInlineKeyboard().Columns(node.columns)
+	for _, child := range node.children {
+		if !child.allowed(c) {
+			continue
+		}
+		op := menuOpen
+		switch child.kind {
+		case menuToggleNode:
+			op = menuToggle
+		case menuActionNode:
+			op = menuAction
+		}
+		kb.Callback(child.buttonLabel(c), m.data(op, append(path[:len(path):len(path)], child.id)))
+	}
+	if len(path) > 0 {
+		kb.Row().Columns(0).Callback("‹ Back", m.data(menuOpen, path[:len(path)-1]))
+	}
+
+	markup, err := kb.Build()
+	if err != nil {
+		return nil, fmt.Errorf("menu %s: %w", m.id, err)
+	}
+	return markup, nil
+}
+
+func (m *Menu) callbackPrefix() string {
+	return "mn:" + m.id + ":"
+}
+
+func (m *Menu) data(op rune, path []string) string {
+	return m.callbackPrefix() + string(op) + strings.Join(path, "/")
+}
+
+// mustMenuID panics on IDs that would make the callback data ambiguous
+func mustMenuID(id string) {
+	if id == "" || strings.ContainsAny(id, "/:") {
+		panic(fmt.Sprintf("gotele: invalid menu ID %q, IDs must be non-empty without \"/\" or \":\"", id))
+	}
+}
+
+func splitMenuPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
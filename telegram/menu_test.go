@@ -0,0 +1,124 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func newTestMenu(sound *bool, admin *bool) *Menu {
+	menu := NewMenu("set", "Settings")
+	notifications := menu.Submenu("n", "Notifications", "Notification settings")
+	notifications.Toggle("s", "Sound", func(c *Context) bool { return *sound }, func(c *Context, on bool) error {
+		*sound = on
+		return nil
+	})
+	notifications.Submenu("q", "Quiet hours", "").WithText(func(c *Context) string { return "Quiet from 22:00" })
+	menu.Submenu("adm", "Admin", "Admin area").WithAccess(func(c *Context) bool { return *admin })
+	return menu
+}
+
+func menuCallback(data string) *Update {
+	return &Update{CallbackQuery: &CallbackQuery{ID: "cb", Data: data, Message: &Message{MessageID: 3, Chat: Chat{ID: 1}}}}
+}
+
+func TestMenuNavigation(t *testing.T) {
+	bot, api := newTestBot(t)
+	sound, admin := false, false
+	menu := newTestMenu(&sound, &admin)
+	router := NewRouter()
+	menu.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	api.on("sendMessage", Message{MessageID: 3})
+	if err := menu.Open(NewContext(ctx, bot, &Update{Message: &Message{Chat: Chat{ID: 1}}})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sent := api.callsTo("sendMessage")[0].Params
+	root := decodeMarkup(t, sent["reply_markup"])
+	if sent["text"] != "Settings" || len(root) != 1 || root[0][0].CallbackData != "mn:set:on" {
+		t.Errorf("Expected root with only the accessible submenu, got %v %v", sent["text"], root)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("mn:set:on"))
+	edit := api.callsTo("editMessageText")[0].Params
+	submenu := decodeMarkup(t, edit["reply_markup"])
+	if edit["text"] != "Notification settings" || len(submenu) != 3 {
+		t.Fatalf("Unexpected submenu %v %v", edit["text"], submenu)
+	}
+	if submenu[0][0].Text != "⬜ Sound" || submenu[0][0].CallbackData != "mn:set:tn/s" {
+		t.Errorf("Unexpected toggle button %+v", submenu[0][0])
+	}
+	if submenu[2][0].Text != "‹ Back" || submenu[2][0].CallbackData != "mn:set:o" {
+		t.Errorf("Unexpected back button %+v", submenu[2][0])
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("mn:set:tn/s"))
+	if !sound {
+		t.Error("Expected toggle to switch the setting on")
+	}
+	toggled := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if toggled[0][0].Text != "✅ Sound" {
+		t.Errorf("Expected toggle to be re-rendered, got %+v", toggled[0][0])
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("mn:set:on/q"))
+	if text := api.callsTo("editMessageText")[1].Params["text"]; text != "Quiet from 22:00" {
+		t.Errorf("Expected dynamic text, got %v", text)
+	}
+}
+
+func TestMenuAccessCheck(t *testing.T) {
+	bot, api := newTestBot(t)
+	sound, admin := false, false
+	menu := newTestMenu(&sound, &admin)
+	router := NewRouter()
+	menu.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	_ = router.HandleUpdate(ctx, menuCallback("mn:set:oadm"))
+	if edits := api.callsTo("editMessageText"); len(edits) != 0 {
+		t.Errorf("Expected the admin menu to stay closed, got %v", edits)
+	}
+	answers := api.callsTo("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Params["text"] != DefaultMenuDeniedText || answers[0].Params["show_alert"] != true {
+		t.Errorf("Expected an access denied alert, got %v", answers)
+	}
+
+	admin = true
+	_ = router.HandleUpdate(ctx, menuCallback("mn:set:oadm"))
+	if edits := api.callsTo("editMessageText"); len(edits) != 1 || edits[0].Params["text"] != "Admin area" {
+		t.Errorf("Expected the admin menu to open, got %v", edits)
+	}
+}
+
+// decodeMarkup decodes a reply_markup parameter sent as a JSON object
+func decodeMarkup(t *testing.T, param interface{}) [][]InlineKeyboardButton {
+	t.Helper()
+	data, _ := json.Marshal(param)
+	var markup InlineKeyboardMarkup
+	if err := json.Unmarshal(data, &markup); err != nil {
+		t.Fatalf("Failed to decode reply markup: %v", err)
+	}
+	return markup.InlineKeyboard
+}
+
+func TestMenuRejectsInvalidIDs(t *testing.T) {
+	constructors := map[string]func(){
+		"menu with colon":    func() { NewMenu("a:b", "Menu") },
+		"empty menu":         func() { NewMenu("", "Menu") },
+		"submenu with slash": func() { NewMenu("m", "Menu").Submenu("a/b", "A", "") },
+		"toggle with colon":  func() { NewMenu("m", "Menu").Toggle("a:b", "A", nil, nil) },
+		"empty action":       func() { NewMenu("m", "Menu").Action("", "A", nil) },
+	}
+	for name, construct := range constructors {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			construct()
+		}()
+	}
+}
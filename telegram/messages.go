@@ -1 +1,196 @@
-package gotele
\ No newline at end of file
+package gotele
+
+import (
+	"slices"
+	"sort"
+)
+
+// EntityText returns the part of text covered by an entity. Entity offsets and lengths
+// count UTF-16 code units, so they cannot index a Go string directly
+func EntityText(text string, entity MessageEntity) string {
+	start, end := utf16Range(text, entity.Offset, entity.Offset+entity.Length)
+	return text[start:end]
+}
+
+// utf16Range converts a range of UTF-16 code unit offsets into byte offsets within s.
+// Offsets past the end of s are clamped to its length
+func utf16Range(s string, from, to int) (int, int) {
+	start, end := len(s), len(s)
+	units := 0
+	for i, r := range s {
+		if units >= from && start == len(s) {
+			start = i
+		}
+		if units >= to {
+			end = i
+			break
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the unit of entity offsets
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// FormattedText returns the message text and its entities, or the caption and its entities
+// for media messages
+func (m *Message) FormattedText() FormattedText {
+	if m.Text == "" && m.Caption != "" {
+		return FormattedText{Text: m.Caption, Entities: m.CaptionEntities}
+	}
+	return FormattedText{Text: m.Text, Entities: m.Entities}
+}
+
+// HTML renders the text for the HTML parse mode, keeping its formatting
+func (f FormattedText) HTML() string {
+	return NewTextBuilder().Append(f.fragments()...).HTML()
+}
+
+// MarkdownV2 renders the text for the MarkdownV2 parse mode, keeping its formatting
+func (f FormattedText) MarkdownV2() string {
+	return NewTextBuilder().Append(f.fragments()...).MarkdownV2()
+}
+
+// EntityMatch is an entity together with the text it covers
+type EntityMatch struct {
+	MessageEntity
+	Text string
+}
+
+// FindEntities returns the entities of the given types in order, or all entities when no type is given
+func (f FormattedText) FindEntities(types ...string) []EntityMatch {
+	var matches []EntityMatch
+	for _, entity := range f.Entities {
+		if len(types) > 0 && !slices.Contains(types, entity.Type) {
+			continue
+		}
+		matches = append(matches, EntityMatch{MessageEntity: entity, Text: EntityText(f.Text, entity)})
+	}
+	return matches
+}
+
+// URLs returns the links in the text: written URLs and the targets of text links
+func (f FormattedText) URLs() []string {
+	var urls []string
+	for _, match := range f.FindEntities("url", "text_link") {
+		if match.Type == "text_link" {
+			urls = append(urls, match.URL)
+		} else {
+			urls = append(urls, match.Text)
+		}
+	}
+	return urls
+}
+
+// Mentions returns the @username mentions in the text
+func (f FormattedText) Mentions() []string {
+	return f.entityTexts("mention")
+}
+
+// Hashtags returns the hashtags in the text, including the leading #
+func (f FormattedText) Hashtags() []string {
+	return f.entityTexts("hashtag")
+}
+
+// TextMentions returns the users mentioned without a username
+func (f FormattedText) TextMentions() []*User {
+	var users []*User
+	for _, match := range f.FindEntities("text_mention") {
+		if match.User != nil {
+			users = append(users, match.User)
+		}
+	}
+	return users
+}
+
+// entityTexts returns the text covered by each entity of the given type
+func (f FormattedText) entityTexts(entityType string) []string {
+	var texts []string
+	for _, match := range f.FindEntities(entityType) {
+		texts = append(texts, match.Text)
+	}
+	return texts
+}
+
+// entitySpan is an entity converted to byte offsets
+type entitySpan struct {
+	entity     MessageEntity
+	start, end int
+}
+
+// fragments converts the text and its entities into nested fragments. Entities that overlap
+// without nesting are split where the enclosing entity ends
+func (f FormattedText) fragments() []interface{} {
+	var spans []entitySpan
+	for _, entity := range f.Entities {
+		start, end := utf16Range(f.Text, entity.Offset, entity.Offset+entity.Length)
+		if start == end {
+			continue
+		}
+		entity.Offset, entity.Length = 0, 0
+		spans = append(spans, entitySpan{entity: entity, start: start, end: end})
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start || spans[i].start == spans[j].start && spans[i].end > spans[j].end
+	})
+
+	type node struct {
+		fragment Fragment
+		end      int
+	}
+	stack := []*node{{end: len(f.Text)}}
+	cursor := 0
+	flush := func(n *node, to int) {
+		if to > cursor {
+			n.fragment.parts = append(n.fragment.parts, f.Text[cursor:to])
+			cursor = to
+		}
+	}
+	closeTo := func(pos int) {
+		for len(stack) > 1 && stack[len(stack)-1].end <= pos {
+			top := stack[len(stack)-1]
+			flush(top, top.end)
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+			parent.fragment.parts = append(parent.fragment.parts, top.fragment)
+		}
+	}
+
+	for i := 0; i < len(spans); i++ {
+		span := spans[i]
+		closeTo(span.start)
+		top := stack[len(stack)-1]
+		if span.end > top.end {
+			rest := entitySpan{entity: span.entity, start: top.end, end: span.end}
+			span.end = top.end
+			j := i + 1
+			for j < len(spans) && (spans[j].start < rest.start || spans[j].start == rest.start && spans[j].end >= rest.end) {
+				j++
+			}
+			spans = append(spans[:j], append([]entitySpan{rest}, spans[j:]...)...)
+		}
+		flush(top, span.start)
+		stack = append(stack, &node{fragment: Fragment{entity: span.entity}, end: span.end})
+	}
+	closeTo(len(f.Text))
+	flush(stack[0], len(f.Text))
+	return stack[0].fragment.parts
+}
@@ -0,0 +1,88 @@
+package gotele
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntityText(t *testing.T) {
+	text := "😀 bold and 𝄞 more"
+	tests := []struct {
+		entity   MessageEntity
+		expected string
+	}{
+		{MessageEntity{Offset: 0, Length: 2}, "😀"},
+		{MessageEntity{Offset: 3, Length: 4}, "bold"},
+		{MessageEntity{Offset: 12, Length: 2}, "𝄞"},
+		{MessageEntity{Offset: 15, Length: 10}, "more"},
+		{MessageEntity{Offset: 40, Length: 2}, ""},
+	}
+	for _, test := range tests {
+		if got := EntityText(text, test.entity); got != test.expected {
+			t.Errorf("EntityText(%d, %d): expected %q, got %q", test.entity.Offset, test.entity.Length, test.expected, got)
+		}
+	}
+}
+
+func TestFormattedTextRender(t *testing.T) {
+	original := Format("😀 ", Bold("Hi <", Italic("𝄞 you")), " ", Link("site", "https://e.com"), " ", Code("x*y"))
+	message := &Message{Text: original.Text, Entities: original.Entities}
+	formatted := message.FormattedText()
+
+	if got := formatted.HTML(); got != `😀 <b>Hi &lt;<i>𝄞 you</i></b> <a href="https://e.com">site</a> <code>x*y</code>` {
+		t.Errorf("Unexpected HTML: %q", got)
+	}
+	if got := formatted.MarkdownV2(); got != "😀 *Hi <_𝄞 you_* [site](https://e.com) `x*y`" {
+		t.Errorf("Unexpected MarkdownV2: %q", got)
+	}
+
+	// Rendering the entities back must reproduce them
+	if rebuilt := NewTextBuilder().Append(formatted.fragments()...).Build(); !reflect.DeepEqual(rebuilt, original) {
+		t.Errorf("Expected round trip, got %+v", rebuilt)
+	}
+
+	caption := &Message{Caption: "photo", CaptionEntities: []MessageEntity{{Type: "bold", Offset: 0, Length: 5}}}
+	if got := caption.FormattedText().HTML(); got != "<b>photo</b>" {
+		t.Errorf("Expected the caption to be rendered, got %q", got)
+	}
+}
+
+func TestFormattedTextOverlappingEntities(t *testing.T) {
+	formatted := FormattedText{Text: "abcdef", Entities: []MessageEntity{
+		{Type: "bold", Offset: 0, Length: 4},
+		{Type: "italic", Offset: 2, Length: 4},
+	}}
+	if got := formatted.HTML(); got != "<b>ab<i>cd</i></b><i>ef</i>" {
+		t.Errorf("Expected the overlapping entity to be split, got %q", got)
+	}
+}
+
+func TestFormattedTextFindEntities(t *testing.T) {
+	text := "🎉 #go @ann https://a.com link Bob"
+	formatted := FormattedText{Text: text, Entities: []MessageEntity{
+		{Type: "hashtag", Offset: 3, Length: 3},
+		{Type: "mention", Offset: 7, Length: 4},
+		{Type: "url", Offset: 12, Length: 13},
+		{Type: "text_link", Offset: 26, Length: 4, URL: "https://b.com"},
+		{Type: "text_mention", Offset: 31, Length: 3, User: &User{ID: 5}},
+	}}
+
+	if got := formatted.Hashtags(); !reflect.DeepEqual(got, []string{"#go"}) {
+		t.Errorf("Unexpected hashtags: %v", got)
+	}
+	if got := formatted.Mentions(); !reflect.DeepEqual(got, []string{"@ann"}) {
+		t.Errorf("Unexpected mentions: %v", got)
+	}
+	if got := formatted.URLs(); !reflect.DeepEqual(got, []string{"https://a.com", "https://b.com"}) {
+		t.Errorf("Unexpected URLs: %v", got)
+	}
+	if got := formatted.TextMentions(); len(got) != 1 || got[0].ID != 5 {
+		t.Errorf("Unexpected text mentions: %v", got)
+	}
+	if got := formatted.FindEntities(); len(got) != 5 || got[4].Text != "Bob" {
+		t.Errorf("Expected all entities, got %+v", got)
+	}
+	if got := formatted.HTML(); got != `🎉 #go @ann https://a.com <a href="https://b.com">link</a> <a href="tg://user?id=5">Bob</a>` {
+		t.Errorf("Unexpected HTML: %q", got)
+	}
+}
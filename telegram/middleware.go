@@ -0,0 +1,89 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a Handler to add behaviour around update handling
+type Middleware func(next Handler) Handler
+
+// Chain combines middlewares into one. The first middleware is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Recover turns a panic in the wrapped handler into a *PanicError carrying the stack trace
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = &PanicError{UpdateID: u.UpdateID, Value: recovered, Stack: debug.Stack()}
+				}
+			}()
+			return next.HandleUpdate(ctx, u)
+		})
+	}
+}
+
+// UpdateLogger logs the type, handling time and outcome of every update. With a nil logf
+// lines are printed to stdout like WebhookLogger does
+func UpdateLogger(logf func(format string, args ...interface{})) Middleware {
+	if logf == nil {
+		logf = func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) error {
+			start := time.Now()
+			err := next.HandleUpdate(ctx, u)
+
+			outcome := "ok"
+			if err != nil && !errors.Is(err, ErrFallthrough) {
+				outcome = err.Error()
+			}
+			logf("[UPDATE] %d %s %v %s", u.UpdateID, u.Type(), time.Since(start), outcome)
+			return err
+		})
+	}
+}
+
+// Timeout bounds the context of the wrapped handler. Handlers must watch the context
+// for the deadline to take effect
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next.HandleUpdate(ctx, u)
+		})
+	}
+}
+
+// ErrorSink receives handler errors
+type ErrorSink func(ctx context.Context, u *Update, err error)
+
+// ReportErrors passes handler errors to sink and reports the update as handled, so that
+// webhook deliveries are not retried by Telegram. ErrFallthrough is passed on untouched
+func ReportErrors(sink ErrorSink) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) error {
+			err := next.HandleUpdate(ctx, u)
+			if err == nil || errors.Is(err, ErrFallthrough) {
+				return err
+			}
+			sink(ctx, u, err)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	handler := Recover()(HandlerFunc(func(ctx context.Context, u *Update) error {
+		panic("boom")
+	}))
+
+	err := handler.HandleUpdate(context.Background(), &Update{UpdateID: 3})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Expected PanicError, got %v", err)
+	}
+	if panicErr.UpdateID != 3 || panicErr.Value != "boom" {
+		t.Errorf("Unexpected panic error: %v", panicErr)
+	}
+	if !strings.Contains(string(panicErr.Stack), "TestRecover") {
+		t.Error("Expected stack trace of the panicking handler")
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	mark := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, u *Update) error {
+				calls = append(calls, name)
+				return next.HandleUpdate(ctx, u)
+			})
+		}
+	}
+
+	router := NewRouter()
+	router.Use(mark("router"))
+	router.NewGroup(1).Use(mark("group")).Message(recordTo(&calls, "handler"))
+
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{}})
+
+	expected := []string{"router", "group", "handler"}
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestUpdateLogger(t *testing.T) {
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	handler := UpdateLogger(logf)(HandlerFunc(func(ctx context.Context, u *Update) error {
+		return errors.New("failed")
+	}))
+
+	_ = handler.HandleUpdate(context.Background(), &Update{UpdateID: 7, Message: &Message{}})
+
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "[UPDATE] 7 message ") || !strings.HasSuffix(lines[0], " failed") {
+		t.Errorf("Unexpected log lines: %q", lines)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	handler := Timeout(10 * time.Millisecond)(HandlerFunc(func(ctx context.Context, u *Update) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	if err := handler.HandleUpdate(context.Background(), &Update{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestReportErrors(t *testing.T) {
+	var reported error
+	sink := func(ctx context.Context, u *Update, err error) { reported = err }
+
+	router := NewRouter()
+	router.Use(ReportErrors(sink), Recover())
+	router.Message(func(ctx context.Context, u *Update) error { panic("boom") })
+
+	if err := router.HandleUpdate(context.Background(), &Update{Message: &Message{}}); err != nil {
+		t.Errorf("Expected reported error to be swallowed, got %v", err)
+	}
+	var panicErr *PanicError
+	if !errors.As(reported, &panicErr) {
+		t.Errorf("Expected panic to reach the sink, got %v", reported)
+	}
+}
@@ -0,0 +1,122 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OffsetStore persists the polling offset so a restarted poller resumes where it stopped
+type OffsetStore interface {
+	// LoadOffset returns the stored offset and when it was saved. A zero time means nothing is stored
+	LoadOffset(ctx context.Context) (offset int, savedAt time.Time, err error)
+	// SaveOffset stores the offset of the next update to process
+	SaveOffset(ctx context.Context, offset int) error
+}
+
+// MemoryOffsetStore keeps the offset in memory
+type MemoryOffsetStore struct {
+	mu      sync.Mutex
+	offset  int
+	savedAt time.Time
+}
+
+// NewMemoryOffsetStore creates an empty in-memory offset store
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{}
+}
+
+// LoadOffset implements OffsetStore
+func (s *MemoryOffsetStore) LoadOffset(ctx context.Context) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset, s.savedAt, nil
+}
+
+// SaveOffset implements OffsetStore
+func (s *MemoryOffsetStore) SaveOffset(ctx context.Context, offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = offset
+	s.savedAt = time.Now()
+	return nil
+}
+
+// FileOffsetStore keeps the offset in a JSON file, replaced atomically on every save
+type FileOffsetStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+type offsetRecord struct {
+	Offset  int       `json:"offset"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// NewFileOffsetStore creates an offset store backed by the file at path
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{path: path}
+}
+
+// LoadOffset implements OffsetStore. A missing file means nothing is stored
+func (s *FileOffsetStore) LoadOffset(ctx context.Context) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to read offset file: %w", err)
+	}
+
+	var record offsetRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse offset file: %w", err)
+	}
+	return record.Offset, record.SavedAt, nil
+}
+
+// SaveOffset implements OffsetStore
+func (s *FileOffsetStore) SaveOffset(ctx context.Context, offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(offsetRecord{Offset: offset, SavedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal offset: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over path,
+// so readers never observe a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
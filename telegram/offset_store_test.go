@@ -0,0 +1,54 @@
+package gotele
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileOffsetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offset.json")
+	store := NewFileOffsetStore(path)
+	ctx := context.Background()
+
+	offset, savedAt, err := store.LoadOffset(ctx)
+	if err != nil || offset != 0 || !savedAt.IsZero() {
+		t.Fatalf("Expected empty store, got %d %v %v", offset, savedAt, err)
+	}
+
+	if err := store.SaveOffset(ctx, 42); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A fresh store reads what the previous process wrote
+	offset, savedAt, err = NewFileOffsetStore(path).LoadOffset(ctx)
+	if err != nil || offset != 42 || savedAt.IsZero() {
+		t.Errorf("Expected stored offset 42, got %d %v %v", offset, savedAt, err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary files to be cleaned up, got %d entries", len(entries))
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.LoadOffset(ctx); err == nil {
+		t.Error("Expected error for corrupt offset file")
+	}
+}
+
+func TestMemoryOffsetStore(t *testing.T) {
+	store := NewMemoryOffsetStore()
+	ctx := context.Background()
+
+	if _, savedAt, _ := store.LoadOffset(ctx); !savedAt.IsZero() {
+		t.Error("Expected empty store")
+	}
+	_ = store.SaveOffset(ctx, 5)
+	if offset, _, _ := store.LoadOffset(ctx); offset != 5 {
+		t.Errorf("Expected offset 5, got %d", offset)
+	}
+}
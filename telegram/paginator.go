@@ -0,0 +1,218 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of items a Paginator shows per page unless configured otherwise
+const DefaultPageSize = 5
+
+// maxJumpPages is the number of page buttons offered in jump to page mode
+const maxJumpPages = 25
+
+// Callback data prefixes of paginator buttons, followed by the paginator ID and a page
+const (
+	paginatorNavigate = "pgn:"
+	paginatorJump     = "pgj:"
+	paginatorNoop     = "pgx:"
+)
+
+// PaginatorItem is one item button of a paginated list
+type PaginatorItem struct {
+	Text         string
+	CallbackData string
+}
+
+// PaginatorOptions configures a Paginator
+type PaginatorOptions struct {
+	ID       string // Identifies the paginator in navigation callbacks, letters and digits
+	PageSize int    // Defaults to DefaultPageSize
+	Columns  int    // Item buttons per row, defaults to 1
+
+	// Count returns the total number of items. The Context is the one passed to Keyboard or,
+	// during navigation, the callback query's, so lists can be scoped to c.Sender()
+	Count func(c *Context) (int, error)
+	// Fetch returns the items of a page. Page is 1-based and offset is (page-1)*pageSize
+	Fetch func(c *Context, page, pageSize int) ([]PaginatorItem, error)
+
+	// JumpToPage makes the "n/N" button open a grid of pages to jump to
+	JumpToPage bool
+}
+
+// Paginator renders a list as an inline keyboard of item buttons followed by a « ‹ n/N › »
+// navigation row. Navigation callbacks registered with Register edit the keyboard in place
+type Paginator struct {
+	options PaginatorOptions
+}
+
+// NewPaginator creates a paginator. ID, Count and Fetch are required
+func NewPaginator(options *PaginatorOptions) (*Paginator, error) {
+	if options == nil {
+		return nil, errors.New("paginator options are required")
+	}
+
+	p := &Paginator{options: *options}
+	if p.options.ID == "" || strings.ContainsAny(p.options.ID, ": ") {
+		return nil, fmt.Errorf("invalid paginator ID %q", p.options.ID)
+	}
+	if p.options.Count == nil || p.options.Fetch == nil {
+		return nil, errors.New("paginator needs Count and Fetch functions")
+	}
+	if p.options.PageSize <= 0 {
+		p.options.PageSize = DefaultPageSize
+	}
+	if p.options.Columns <= 0 {
+		p.options.Columns = 1
+	}
+	return p, nil
+}
+
+// Keyboard renders the given page, clamped to the available pages
+func (p *Paginator) Keyboard(c *Context, page int) (*InlineKeyboardMarkup, error) {
+	pages, err := p.pages(c)
+	if err != nil {
+		return nil, err
+	}
+	page = clampPage(page, pages)
+
+	items, err := p.options.Fetch(c, page, p.options.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page %d: %w", page, err)
+	}
+
+	kb := NewInlineKeyboard().Columns(p.options.Columns)
+	for _, item := range items {
+		kb.Callback(item.Text, item.CallbackData)
+	}
+	if pages > 1 {
+		kb.Row().Columns(0)
+		if page > 1 {
+			kb.Callback("«", p.data(paginatorNavigate, 1)).Callback("‹", p.data(paginatorNavigate, page-1))
+		}
+		label := fmt.Sprintf("%d/%d", page, pages)
+		if p.options.JumpToPage {
+			kb.Callback(label, p.data(paginatorJump, page))
+		} else {
+			kb.Callback(label, p.data(paginatorNoop, page))
+		}
+		if page < pages {
+			kb.Callback("›", p.data(paginatorNavigate, page+1)).Callback("»", p.data(paginatorNavigate, pages))
+		}
+	}
+	return kb.Build()
+}
+
+// JumpKeyboard renders a grid of pages to jump to, marking the current one
+func (p *Paginator) JumpKeyboard(c *Context, page int) (*InlineKeyboardMarkup, error) {
+	pages, err := p.pages(c)
+	if err != nil {
+		return nil, err
+	}
+	page = clampPage(page, pages)
+
+	kb := NewInlineKeyboard().Columns(5)
+	for _, target := range jumpPages(pages) {
+		text := strconv.Itoa(target)
+		if target == page {
+			text = "· " + text + " ·"
+		}
+		kb.Callback(text, p.data(paginatorNavigate, target))
+	}
+	kb.Row().Columns(0).Callback("‹ Back", p.data(paginatorNavigate, page))
+	return kb.Build()
+}
+
+// Register handles the paginator's navigation callbacks in the group
+func (p *Paginator) Register(g *Group) {
+	g.handleMatch(UpdateTypeCallbackQuery, func(ctx context.Context, u *Update) (context.Context, bool) {
+		_, _, ok := p.parse(u.CallbackQuery.Data)
+		return ctx, ok
+	}, HandleContext(p.handle), nil)
+}
+
+// handle edits the keyboard for a navigation callback
+func (p *Paginator) handle(c *Context) error {
+	action, page, _ := p.parse(c.Update.CallbackQuery.Data)
+
+	var markup *InlineKeyboardMarkup
+	var err error
+	switch action {
+	case paginatorNoop:
+		return nil
+	case paginatorJump:
+		markup, err = p.JumpKeyboard(c, page)
+	default:
+		markup, err = p.Keyboard(c, page)
+	}
+	if err != nil {
+		return err
+	}
+	return c.EditReplyMarkup(markup)
+}
+
+// parse splits navigation callback data of this paginator into action prefix and page
+func (p *Paginator) parse(data string) (string, int, bool) {
+	for _, action := range []string{paginatorNavigate, paginatorJump, paginatorNoop} {
+		rest, ok := strings.CutPrefix(data, action+p.options.ID+":")
+		if !ok {
+			continue
+		}
+		page, err := strconv.Atoi(rest)
+		if err != nil {
+			return "", 0, false
+		}
+		return action, page, true
+	}
+	return "", 0, false
+}
+
+func (p *Paginator) data(action string, page int) string {
+	return action + p.options.ID + ":" + strconv.Itoa(page)
+}
+
+// pages returns the number of pages, at least 1
+func (p *Paginator) pages(c *Context) (int, error) {
+	count, err := p.options.Count(c)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	pages := (count + p.options.PageSize - 1) / p.options.PageSize
+	if pages < 1 {
+		pages = 1
+	}
+	return pages, nil
+}
+
+func clampPage(page, pages int) int {
+	if page < 1 {
+		return 1
+	}
+	if page > pages {
+		return pages
+	}
+	return page
+}
+
+// jumpPages returns up to maxJumpPages pages spread evenly from the first to the last
+func jumpPages(pages int) []int {
+	if pages <= maxJumpPages {
+		all := make([]int, pages)
+		for i := range all {
+			all[i] = i + 1
+		}
+		return all
+	}
+
+	spread := make([]int, 0, maxJumpPages)
+	for i := 0; i < maxJumpPages; i++ {
+		page := 1 + i*(pages-1)/(maxJumpPages-1)
+		if len(spread) == 0 || spread[len(spread)-1] != page {
+			spread = append(spread, page)
+		}
+	}
+	return spread
+}
@@ -0,0 +1,171 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func newTestPaginator(t *testing.T, count int, jump bool) *Paginator {
+	t.Helper()
+	paginator, err := NewPaginator(&PaginatorOptions{
+		ID:         "orders",
+		PageSize:   2,
+		JumpToPage: jump,
+		Count:      func(c *Context) (int, error) { return count, nil },
+		Fetch: func(c *Context, page, pageSize int) ([]PaginatorItem, error) {
+			var items []PaginatorItem
+			for i := (page - 1) * pageSize; i < page*pageSize && i < count; i++ {
+				items = append(items, PaginatorItem{Text: fmt.Sprintf("Order %d", i+1), CallbackData: fmt.Sprintf("order:%d", i+1)})
+			}
+			return items, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return paginator
+}
+
+func buttonTexts(row []InlineKeyboardButton) []string {
+	texts := make([]string, len(row))
+	for i, button := range row {
+		texts[i] = button.Text
+	}
+	return texts
+}
+
+// editedKeyboard decodes the reply markup sent with an editMessageReplyMarkup call
+func editedKeyboard(t *testing.T, call apiCall) [][]InlineKeyboardButton {
+	t.Helper()
+	var markup InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(call.Params["reply_markup"].(string)), &markup); err != nil {
+		t.Fatalf("Failed to decode reply markup: %v", err)
+	}
+	return markup.InlineKeyboard
+}
+
+func TestPaginatorKeyboard(t *testing.T) {
+	paginator := newTestPaginator(t, 7, false)
+	ctx := NewContext(context.Background(), nil, &Update{})
+
+	tests := []struct {
+		page       int
+		firstItem  string
+		navigation string
+	}{
+		{1, "Order 1", "[1/4 › »]"},
+		{2, "Order 3", "[« ‹ 2/4 › »]"},
+		{4, "Order 7", "[« ‹ 4/4]"},
+		{9, "Order 7", "[« ‹ 4/4]"},
+	}
+	for _, test := range tests {
+		keyboard, err := paginator.Keyboard(ctx, test.page)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rows := keyboard.InlineKeyboard
+		if rows[0][0].Text != test.firstItem {
+			t.Errorf("Page %d: expected first item %q, got %q", test.page, test.firstItem, rows[0][0].Text)
+		}
+		if navigation := fmt.Sprint(buttonTexts(rows[len(rows)-1])); navigation != test.navigation {
+			t.Errorf("Page %d: expected navigation %s, got %s", test.page, test.navigation, navigation)
+		}
+	}
+
+	single := newTestPaginator(t, 2, false)
+	keyboard, _ := single.Keyboard(ctx, 1)
+	if len(keyboard.InlineKeyboard) != 2 {
+		t.Errorf("Expected no navigation row for a single page, got %v", keyboard.InlineKeyboard)
+	}
+}
+
+func TestPaginatorNavigation(t *testing.T) {
+	bot, api := newTestBot(t)
+	paginator := newTestPaginator(t, 7, true)
+	router := NewRouter()
+	paginator.Register(router.Group)
+
+	ctx := ContextWithBot(context.Background(), bot)
+	query := &CallbackQuery{ID: "cb", Data: "pgn:orders:3", Message: &Message{MessageID: 4, Chat: Chat{ID: 1}}}
+	if err := router.HandleUpdate(ctx, &Update{CallbackQuery: query}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	edits := api.callsTo("editMessageReplyMarkup")
+	if len(edits) != 1 || edits[0].Params["message_id"] != float64(4) {
+		t.Fatalf("Expected the keyboard to be edited in place, got %v", edits)
+	}
+	markup := editedKeyboard(t, edits[0])
+	if markup[0][0].Text != "Order 5" {
+		t.Errorf("Expected page 3 to start with Order 5, got %v", markup[0])
+	}
+	if answers := api.callsTo("answerCallbackQuery"); len(answers) != 1 {
+		t.Errorf("Expected the callback query to be answered, got %v", answers)
+	}
+
+	query.Data = "pgj:orders:3"
+	_ = router.HandleUpdate(ctx, &Update{CallbackQuery: query})
+	markup = editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[1])
+	if len(markup) != 2 || len(markup[0]) != 4 {
+		t.Errorf("Expected a jump grid of 4 pages and a back row, got %v", markup)
+	}
+}
+
+func TestPaginatorScopedToSender(t *testing.T) {
+	bot, api := newTestBot(t)
+	orders := map[int64]int{1: 3, 2: 12}
+	paginator, err := NewPaginator(&PaginatorOptions{
+		ID:       "mine",
+		PageSize: 2,
+		Count:    func(c *Context) (int, error) { return orders[c.Sender().ID], nil },
+		Fetch: func(c *Context, page, pageSize int) ([]PaginatorItem, error) {
+			text := fmt.Sprintf("User %d page %d", c.Sender().ID, page)
+			return []PaginatorItem{{Text: text, CallbackData: "x"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	router := NewRouter()
+	paginator.Register(router.Group)
+
+	ctx := ContextWithBot(context.Background(), bot)
+	query := &CallbackQuery{ID: "cb", From: &User{ID: 2}, Data: "pgn:mine:5", Message: &Message{MessageID: 4, Chat: Chat{ID: 2}}}
+	if err := router.HandleUpdate(ctx, &Update{CallbackQuery: query}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	markup := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if markup[0][0].Text != "User 2 page 5" || fmt.Sprint(buttonTexts(markup[1])) != "[« ‹ 5/6 › »]" {
+		t.Errorf("Expected the list of user 2, got %v", markup)
+	}
+}
+
+func TestNewPaginatorValidation(t *testing.T) {
+	count := func(c *Context) (int, error) { return 0, nil }
+	fetch := func(c *Context, page, pageSize int) ([]PaginatorItem, error) { return nil, nil }
+
+	invalid := map[string]*PaginatorOptions{
+		"nil":       nil,
+		"empty ID":  {Count: count, Fetch: fetch},
+		"ID with :": {ID: "a:b", Count: count, Fetch: fetch},
+		"no Count":  {ID: "items", Fetch: fetch},
+		"no Fetch":  {ID: "items", Count: count},
+	}
+	for name, options := range invalid {
+		if _, err := NewPaginator(options); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJumpPages(t *testing.T) {
+	pages := jumpPages(100)
+	if len(pages) != maxJumpPages || pages[0] != 1 || pages[len(pages)-1] != 100 {
+		t.Errorf("Unexpected jump pages: %v", pages)
+	}
+	if pages := jumpPages(3); fmt.Sprint(pages) != "[1 2 3]" {
+		t.Errorf("Unexpected jump pages: %v", pages)
+	}
+}
@@ -0,0 +1,153 @@
+package gotele
+
+import "strings"
+
+// Plural categories as defined by CLDR
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// PluralRule returns the plural category of an integer count
+type PluralRule func(n int64) string
+
+// pluralRules holds the CLDR cardinal rules for integers, keyed by base language or by full
+// tag where a regional variant differs
+var pluralRules = map[string]PluralRule{}
+
+func init() {
+	register := func(rule PluralRule, languages ...string) {
+		for _, language := range languages {
+			pluralRules[language] = rule
+		}
+	}
+
+	register(func(n int64) string { return PluralOther },
+		"ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km")
+	register(func(n int64) string {
+		if n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, "en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et", "it", "es", "el", "hu", "tr",
+		"bg", "ca", "eu", "gl", "ka", "az", "kk", "uz", "af", "sq", "ky", "mn", "ta", "te", "ur", "pt-pt")
+	register(func(n int64) string {
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, "fr", "pt", "hi", "bn", "fa", "am", "gu", "kn", "zu", "hy")
+	register(func(n int64) string {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, "ru", "uk", "be")
+	register(func(n int64) string {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralOther
+	}, "hr", "sr", "bs")
+	register(func(n int64) string {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, "pl")
+	register(func(n int64) string {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n >= 2 && n <= 4:
+			return PluralFew
+		}
+		return PluralOther
+	}, "cs", "sk")
+	register(func(n int64) string {
+		switch {
+		case n%10 == 1 && (n%100 < 11 || n%100 > 19):
+			return PluralOne
+		case n%10 >= 2 && (n%100 < 11 || n%100 > 19):
+			return PluralFew
+		}
+		return PluralOther
+	}, "lt")
+	register(func(n int64) string {
+		switch {
+		case n%10 == 0 || n%100 >= 11 && n%100 <= 19:
+			return PluralZero
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		}
+		return PluralOther
+	}, "lv")
+	register(func(n int64) string {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n == 0 || n%100 >= 1 && n%100 <= 19:
+			return PluralFew
+		}
+		return PluralOther
+	}, "ro")
+	register(func(n int64) string {
+		switch {
+		case n == 0:
+			return PluralZero
+		case n == 1:
+			return PluralOne
+		case n == 2:
+			return PluralTwo
+		case n%100 >= 3 && n%100 <= 10:
+			return PluralFew
+		case n%100 >= 11:
+			return PluralMany
+		}
+		return PluralOther
+	}, "ar")
+	register(func(n int64) string {
+		switch n {
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		}
+		return PluralOther
+	}, "he", "iw")
+}
+
+// PluralCategory returns the plural category of count in a language such as "ru" or "pt-BR".
+// Languages without a known rule use the English one
+func PluralCategory(language string, count int64) string {
+	if count < 0 {
+		count = -count
+	}
+	tag := normalizeLanguage(language)
+	if rule, ok := pluralRules[tag]; ok {
+		return rule(count)
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if rule, ok := pluralRules[base]; ok {
+		return rule(count)
+	}
+	return pluralRules["en"](count)
+}
+
+// normalizeLanguage lower-cases a language tag and uses hyphens as separators
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+}
@@ -0,0 +1,31 @@
+package gotele
+
+import "testing"
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		language string
+		counts   map[int64]string
+	}{
+		{"en", map[int64]string{0: "other", 1: "one", 2: "other", -1: "one"}},
+		{"fr", map[int64]string{0: "one", 1: "one", 2: "other"}},
+		{"ru", map[int64]string{1: "one", 21: "one", 11: "many", 3: "few", 14: "many", 22: "few", 5: "many"}},
+		{"uk-UA", map[int64]string{101: "one", 112: "many", 34: "few"}},
+		{"pl", map[int64]string{1: "one", 21: "many", 22: "few", 12: "many"}},
+		{"cs", map[int64]string{1: "one", 4: "few", 5: "other"}},
+		{"ar", map[int64]string{0: "zero", 1: "one", 2: "two", 103: "few", 111: "many", 100: "other"}},
+		{"ja", map[int64]string{1: "other"}},
+		{"pt_BR", map[int64]string{0: "one", 2: "other"}},
+		{"pt-PT", map[int64]string{0: "other", 1: "one", 2: "other"}},
+		{"ro", map[int64]string{0: "few", 1: "one", 2: "few", 19: "few", 20: "other", 101: "few", 120: "other"}},
+		{"hy", map[int64]string{0: "one", 1: "one", 2: "other"}},
+		{"xx", map[int64]string{1: "one", 3: "other"}},
+	}
+	for _, test := range tests {
+		for count, expected := range test.counts {
+			if got := PluralCategory(test.language, count); got != expected {
+				t.Errorf("PluralCategory(%q, %d): expected %s, got %s", test.language, count, expected, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrFallthrough can be returned by a routed handler to pass the update on to the next matching route
+var ErrFallthrough = errors.New("fallthrough to the next matching handler")
+
+// Router dispatches updates to handlers registered by update type and filtered by content.
+// Routes are organised in groups: groups run in descending priority, and within a group the
+// first matching route handles the update. Dispatch stops after the first group that handled
+// the update unless that group falls through
+type Router struct {
+	*Group
+
+	groups      []*Group
+	notFound    HandlerFunc
+	middlewares []Middleware
+
+	mu          sync.Mutex
+	botUsername string
+}
+
+// Group is a set of routes sharing a priority and optional filters
+type Group struct {
+	router      *Router
+	priority    int
+	passThrough bool
+	filters     []Filter
+	routes      []route
+	middlewares []Middleware
+}
+
+type route struct {
+	updateType string // Empty matches every update type
+	filters    []Filter
+	match      func(ctx context.Context, u *Update) (context.Context, bool) // Optional, may enrich the context
+	handler    HandlerFunc
+}
+
+// NewRouter creates an empty router. Its own registration methods use a group with priority 0
+func NewRouter() *Router {
+	r := &Router{}
+	r.Group = r.NewGroup(0)
+	return r
+}
+
+// NewGroup adds a route group. Groups with a higher priority see updates first.
+// Filters given here apply to every route in the group
+func (r *Router) NewGroup(priority int, filters ...Filter) *Group {
+	g := &Group{router: r, priority: priority, filters: filters}
+	r.groups = append(r.groups, g)
+	sort.SliceStable(r.groups, func(i, j int) bool {
+		return r.groups[i].priority > r.groups[j].priority
+	})
+	return g
+}
+
+// NotFound sets the handler called for updates no route handled
+func (r *Router) NotFound(handler HandlerFunc) {
+	r.notFound = handler
+}
+
+// Use adds middlewares wrapping the handling of every update, including the NotFound handler.
+// They run in the order given, before any group middleware
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// HandleUpdate implements Handler
+func (r *Router) HandleUpdate(ctx context.Context, u *Update) error {
+	if len(r.middlewares) == 0 {
+		return r.dispatch(ctx, u)
+	}
+	return Chain(r.middlewares...)(HandlerFunc(r.dispatch)).HandleUpdate(ctx, u)
+}
+
+// dispatch passes the update to the groups in priority order
+func (r *Router) dispatch(ctx context.Context, u *Update) error {
+	handled := false
+	for _, g := range r.groups {
+		groupHandled, err := g.dispatch(ctx, u)
+		if err != nil {
+			return err
+		}
+		if groupHandled {
+			handled = true
+			if !g.passThrough {
+				return nil
+			}
+		}
+	}
+
+	if !handled && r.notFound != nil {
+		return r.notFound(ctx, u)
+	}
+	return nil
+}
+
+// dispatch runs the first matching route of the group, reporting whether one handled the update
+func (g *Group) dispatch(ctx context.Context, u *Update) (bool, error) {
+	if !matchAll(g.filters, u) {
+		return false, nil
+	}
+
+	updateType := u.Type()
+	for _, rt := range g.routes {
+		if rt.updateType != "" && rt.updateType != updateType {
+			continue
+		}
+		if !matchAll(rt.filters, u) {
+			continue
+		}
+		routeCtx := ctx
+		if rt.match != nil {
+			var ok bool
+			if routeCtx, ok = rt.match(ctx, u); !ok {
+				continue
+			}
+		}
+
+		var err error
+		if len(g.middlewares) == 0 {
+			err = rt.handler(routeCtx, u)
+		} else {
+			err = Chain(g.middlewares...)(rt.handler).HandleUpdate(routeCtx, u)
+		}
+		if errors.Is(err, ErrFallthrough) {
+			continue
+		}
+		return true, err
+	}
+	return false, nil
+}
+
+// Fallthrough lets updates handled by this group continue to groups with a lower priority
+func (g *Group) Fallthrough() *Group {
+	g.passThrough = true
+	return g
+}
+
+// Use adds middlewares wrapping each handler of the group
+func (g *Group) Use(middlewares ...Middleware) *Group {
+	g.middlewares = append(g.middlewares, middlewares...)
+	return g
+}
+
+// Handle registers a handler for every update type
+func (g *Group) Handle(handler HandlerFunc, filters ...Filter) {
+	g.handle("", handler, filters)
+}
+
+// HandleType registers a handler for the given update type, such as UpdateTypeMessage
+func (g *Group) HandleType(updateType string, handler HandlerFunc, filters ...Filter) {
+	g.handle(updateType, handler, filters)
+}
+
+// Message registers a handler for new messages
+func (g *Group) Message(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeMessage, handler, filters)
+}
+
+// EditedMessage registers a handler for edited messages
+func (g *Group) EditedMessage(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeEditedMessage, handler, filters)
+}
+
+// ChannelPost registers a handler for new channel posts
+func (g *Group) ChannelPost(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeChannelPost, handler, filters)
+}
+
+// EditedChannelPost registers a handler for edited channel posts
+func (g *Group) EditedChannelPost(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeEditedChannelPost, handler, filters)
+}
+
+// InlineQuery registers a handler for inline queries
+func (g *Group) InlineQuery(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeInlineQuery, handler, filters)
+}
+
+// ChosenInlineResult registers a handler for chosen inline results
+func (g *Group) ChosenInlineResult(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeChosenInlineResult, handler, filters)
+}
+
+// CallbackQuery registers a handler for callback queries
+func (g *Group) CallbackQuery(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeCallbackQuery, handler, filters)
+}
+
+// ShippingQuery registers a handler for shipping queries
+func (g *Group) ShippingQuery(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeShippingQuery, handler, filters)
+}
+
+// PreCheckoutQuery registers a handler for pre-checkout queries
+func (g *Group) PreCheckoutQuery(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypePreCheckoutQuery, handler, filters)
+}
+
+// Poll registers a handler for poll state updates
+func (g *Group) Poll(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypePoll, handler, filters)
+}
+
+// PollAnswer registers a handler for poll answers
+func (g *Group) PollAnswer(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypePollAnswer, handler, filters)
+}
+
+// MyChatMember registers a handler for changes of the bot's own chat membership
+func (g *Group) MyChatMember(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeMyChatMember, handler, filters)
+}
+
+// ChatMember registers a handler for chat member updates
+func (g *Group) ChatMember(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeChatMember, handler, filters)
+}
+
+// ChatJoinRequest registers a handler for chat join requests
+func (g *Group) ChatJoinRequest(handler HandlerFunc, filters ...Filter) {
+	g.handle(UpdateTypeChatJoinRequest, handler, filters)
+}
+
+func (g *Group) handle(updateType string, handler HandlerFunc, filters []Filter) {
+	g.handleMatch(updateType, nil, handler, filters)
+}
+
+func (g *Group) handleMatch(updateType string, match func(context.Context, *Update) (context.Context, bool), handler HandlerFunc, filters []Filter) {
+	g.routes = append(g.routes, route{updateType: updateType, filters: filters, match: match, handler: handler})
+}
@@ -0,0 +1,115 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func recordTo(calls *[]string, name string) HandlerFunc {
+	return func(ctx context.Context, u *Update) error {
+		*calls = append(*calls, name)
+		return nil
+	}
+}
+
+func TestRouterDispatchByType(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Message(recordTo(&calls, "message"))
+	router.CallbackQuery(recordTo(&calls, "callback"))
+
+	_ = router.HandleUpdate(context.Background(), &Update{CallbackQuery: &CallbackQuery{ID: "1"}})
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{Text: "hi"}})
+
+	if len(calls) != 2 || calls[0] != "callback" || calls[1] != "message" {
+		t.Errorf("Unexpected calls: %v", calls)
+	}
+}
+
+func TestRouterFirstMatchWins(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Message(recordTo(&calls, "photo"), HasPhoto())
+	router.Message(recordTo(&calls, "text"), HasText())
+	router.Message(recordTo(&calls, "any"))
+
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{Text: "hi"}})
+
+	if len(calls) != 1 || calls[0] != "text" {
+		t.Errorf("Expected only the text handler, got %v", calls)
+	}
+}
+
+func TestRouterGroupPriority(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Message(recordTo(&calls, "default"))
+	router.NewGroup(10).Fallthrough().Message(recordTo(&calls, "high"))
+	router.NewGroup(5).Message(recordTo(&calls, "medium"))
+
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{}})
+
+	if len(calls) != 2 || calls[0] != "high" || calls[1] != "medium" {
+		t.Errorf("Expected high then medium, got %v", calls)
+	}
+}
+
+func TestRouterGroupFilters(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.NewGroup(1, FromUsers(42)).Message(recordTo(&calls, "admin"))
+	router.Message(recordTo(&calls, "user"))
+
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{From: &User{ID: 7}}})
+	_ = router.HandleUpdate(context.Background(), &Update{Message: &Message{From: &User{ID: 42}}})
+
+	if len(calls) != 2 || calls[0] != "user" || calls[1] != "admin" {
+		t.Errorf("Unexpected calls: %v", calls)
+	}
+}
+
+func TestRouterErrFallthrough(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Message(func(ctx context.Context, u *Update) error {
+		calls = append(calls, "first")
+		return ErrFallthrough
+	})
+	router.Message(recordTo(&calls, "second"))
+
+	if err := router.HandleUpdate(context.Background(), &Update{Message: &Message{}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(calls) != 2 || calls[1] != "second" {
+		t.Errorf("Expected fallthrough to second handler, got %v", calls)
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Message(recordTo(&calls, "message"))
+
+	if err := router.HandleUpdate(context.Background(), &Update{InlineQuery: &InlineQuery{}}); err != nil {
+		t.Errorf("Expected unmatched update to be ignored, got %v", err)
+	}
+
+	router.NotFound(recordTo(&calls, "not_found"))
+	_ = router.HandleUpdate(context.Background(), &Update{InlineQuery: &InlineQuery{}})
+
+	if len(calls) != 1 || calls[0] != "not_found" {
+		t.Errorf("Expected NotFound handler, got %v", calls)
+	}
+}
+
+func TestRouterHandlerError(t *testing.T) {
+	failure := errors.New("boom")
+	router := NewRouter()
+	router.Handle(func(ctx context.Context, u *Update) error { return failure })
+
+	var handler Handler = router
+	if err := handler.HandleUpdate(context.Background(), &Update{Poll: &Poll{}}); !errors.Is(err, failure) {
+		t.Errorf("Expected handler error, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package gotele
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSelectionTTL is how long a SelectionKeyboard keeps server-side state
+const DefaultSelectionTTL = 24 * time.Hour
+
+// SelectionOptions configures a SelectionKeyboard
+type SelectionOptions struct {
+	ID       string   // Identifies the keyboard in callbacks, letters and digits
+	Options  []string // Option labels, selections refer to their indexes
+	Multiple bool     // Checkboxes when true, radio buttons otherwise
+	Min, Max int      // Bounds on the number of selected options, 0 for none
+	Columns  int      // Option buttons per row, defaults to 1
+	DoneText string   // Label of the button confirming the selection, defaults to "Done"
+
+	// Storage keeps the selection server-side, keyed by message. Without it the selection is
+	// encoded in the callback data, which allows at most 64 options
+	Storage SessionStorage
+	TTL     time.Duration // Lifetime of stored selections, defaults to DefaultSelectionTTL
+
+	// OnDone receives the selected option indexes in ascending order
+	OnDone func(c *Context, selected []int) error
+}
+
+// SelectionKeyboard is an inline keyboard of checkboxes or radio buttons. Toggling an option
+// re-renders the keyboard in place and the Done button delivers the selection
+type SelectionKeyboard struct {
+	options SelectionOptions
+}
+
+// NewSelectionKeyboard creates a selection keyboard. ID, Options and OnDone are required
+func NewSelectionKeyboard(options *SelectionOptions) (*SelectionKeyboard, error) {
+	if options == nil {
+		return nil, errors.New("selection keyboard options are required")
+	}
+
+	sk := &SelectionKeyboard{options: *options}
+	if err := validWidgetID(sk.options.ID); err != nil {
+		return nil, err
+	}
+	if len(sk.options.Options) == 0 || sk.options.OnDone == nil {
+		return nil, errors.New("selection keyboard needs options and an OnDone handler")
+	}
+	if sk.options.Storage == nil && len(sk.options.Options) > 64 {
+		return nil, fmt.Errorf("selection keyboard has %d options, at most 64 fit into callback data", len(sk.options.Options))
+	}
+	if sk.options.Columns <= 0 {
+		sk.options.Columns = 1
+	}
+	if sk.options.DoneText == "" {
+		sk.options.DoneText = "Done"
+	}
+	if sk.options.TTL <= 0 {
+		sk.options.TTL = DefaultSelectionTTL
+	}
+	return sk, nil
+}
+
+// Keyboard renders the keyboard with the given options selected. With server-side storage the
+// first toggle picks the initial selection up from the rendered keyboard
+func (sk *SelectionKeyboard) Keyboard(selected []int) (*InlineKeyboardMarkup, error) {
+	state := sk.state(selected)
+	kb := NewInlineKeyboard().Columns(sk.options.Columns)
+	for i, label := range sk.options.Options {
+		kb.Callback(sk.label(label, state[i]), sk.data("t"+strconv.Itoa(i), state))
+	}
+	kb.Row().Columns(0).Callback(sk.options.DoneText, sk.data("d", state))
+	return kb.Build()
+}
+
+// Register handles the keyboard's callbacks in the group
+func (sk *SelectionKeyboard) Register(g *Group) {
+	prefix := "sel:" + sk.options.ID + ":"
+	g.CallbackQuery(HandleContext(sk.handle), func(u *Update) bool {
+		return strings.HasPrefix(u.CallbackQuery.Data, prefix)
+	})
+}
+
+func (sk *SelectionKeyboard) handle(c *Context) error {
+	data := strings.TrimPrefix(c.Update.CallbackQuery.Data, "sel:"+sk.options.ID+":")
+	action, encoded, _ := strings.Cut(data, ".")
+
+	selected, version, err := sk.load(c, encoded)
+	if err != nil {
+		return err
+	}
+
+	if action == "d" {
+		// The callback data may be forged, so the bounds are checked again on delivery
+		selected = selectedIndexes(sk.state(selected))
+		if len(selected) < sk.options.Min {
+			return c.AnswerAdvanced(&AnswerCallbackQueryOptions{Text: fmt.Sprintf("Select at least %d", sk.options.Min), ShowAlert: true})
+		}
+		if limit := sk.maxSelected(); limit > 0 && len(selected) > limit {
+			return c.AnswerAdvanced(&AnswerCallbackQueryOptions{Text: fmt.Sprintf("Select at most %d", limit), ShowAlert: true})
+		}
+		return sk.options.OnDone(c, selected)
+	}
+
+	index, err := strconv.Atoi(strings.TrimPrefix(action, "t"))
+	if err != nil || !strings.HasPrefix(action, "t") || index < 0 || index >= len(sk.options.Options) {
+		return nil
+	}
+	state := sk.state(selected)
+	switch {
+	case state[index]:
+		delete(state, index)
+	case !sk.options.Multiple:
+		state = map[int]bool{index: true}
+	case sk.options.Max > 0 && len(state) >= sk.options.Max:
+		return c.AnswerAdvanced(&AnswerCallbackQueryOptions{Text: fmt.Sprintf("Select at most %d", sk.options.Max), ShowAlert: true})
+	default:
+		state[index] = true
+	}
+
+	selected = selectedIndexes(state)
+	if sk.options.Storage != nil {
+		if err := sk.save(c, selected, version); err != nil {
+			return err
+		}
+	}
+	markup, err := sk.Keyboard(selected)
+	if err != nil {
+		return err
+	}
+	return c.EditReplyMarkup(markup)
+}
+
+// load returns the current selection from the callback data or the storage
+func (sk *SelectionKeyboard) load(c *Context, encoded string) ([]int, int64, error) {
+	if sk.options.Storage == nil {
+		mask, err := strconv.ParseUint(encoded, 36, 64)
+		if err != nil {
+			return nil, 0, nil
+		}
+		var selected []int
+		for i := range sk.options.Options {
+			if mask&(1<<uint(i)) != 0 {
+				selected = append(selected, i)
+			}
+		}
+		return selected, 0, nil
+	}
+
+	record, err := sk.options.Storage.LoadSession(c, sk.storageKey(c))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load selection: %w", err)
+	}
+	if record == nil {
+		return sk.rendered(c), 0, nil
+	}
+	var selected []int
+	if err := json.Unmarshal(record.Data, &selected); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode selection: %w", err)
+	}
+	return selected, record.Version, nil
+}
+
+func (sk *SelectionKeyboard) save(c *Context, selected []int, version int64) error {
+	data, err := json.Marshal(selected)
+	if err != nil {
+		return fmt.Errorf("failed to encode selection: %w", err)
+	}
+	record := &SessionRecord{Data: data, ExpiresAt: time.Now().Add(sk.options.TTL)}
+	if err := sk.options.Storage.SaveSession(c, sk.storageKey(c), record, version); err != nil {
+		return fmt.Errorf("failed to save selection: %w", err)
+	}
+	return nil
+}
+
+// rendered reads the selection shown by the keyboard of the callback query's message
+func (sk *SelectionKeyboard) rendered(c *Context) []int {
+	message := c.Update.CallbackQuery.Message
+	if message == nil || message.ReplyMarkup == nil {
+		return nil
+	}
+	var selected []int
+	for _, row := range message.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			action := strings.TrimPrefix(button.CallbackData, "sel:"+sk.options.ID+":t")
+			index, err := strconv.Atoi(action)
+			if err == nil && action != button.CallbackData && strings.HasPrefix(button.Text, sk.marker(true)) {
+				selected = append(selected, index)
+			}
+		}
+	}
+	return selected
+}
+
+func (sk *SelectionKeyboard) storageKey(c *Context) string {
+	query := c.Update.CallbackQuery
+	if query.Message != nil {
+		return fmt.Sprintf("sel:%s:%d:%d", sk.options.ID, query.Message.Chat.ID, query.Message.MessageID)
+	}
+	return "sel:" + sk.options.ID + ":inline:" + query.InlineMessageID
+}
+
+// state turns a list of indexes into a set, dropping indexes out of range
+func (sk *SelectionKeyboard) state(selected []int) map[int]bool {
+	state := map[int]bool{}
+	for _, index := range selected {
+		if index >= 0 && index < len(sk.options.Options) {
+			state[index] = true
+		}
+	}
+	return state
+}
+
+// data returns callback data for an action. Without storage the selection is appended as a
+// base 36 bit mask
+func (sk *SelectionKeyboard) data(action string, state map[int]bool) string {
+	data := "sel:" + sk.options.ID + ":" + action
+	if sk.options.Storage != nil {
+		return data
+	}
+	var mask uint64
+	for index := range state {
+		mask |= 1 << uint(index)
+	}
+	return data + "." + strconv.FormatUint(mask, 36)
+}
+
+// maxSelected returns the most options that may be selected, 0 for no limit
+func (sk *SelectionKeyboard) maxSelected() int {
+	if !sk.options.Multiple {
+		return 1
+	}
+	return sk.options.Max
+}
+
+func (sk *SelectionKeyboard) label(label string, selected bool) string {
+	return sk.marker(selected) + label
+}
+
+func (sk *SelectionKeyboard) marker(selected bool) string {
+	switch {
+	case sk.options.Multiple && selected:
+		return "✅ "
+	case sk.options.Multiple:
+		return "⬜ "
+	case selected:
+		return "🔘 "
+	default:
+		return "⚪ "
+	}
+}
+
+func selectedIndexes(state map[int]bool) []int {
+	selected := make([]int, 0, len(state))
+	for index := range state {
+		selected = append(selected, index)
+	}
+	sort.Ints(selected)
+	return selected
+}
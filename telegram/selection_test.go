@@ -0,0 +1,126 @@
+package gotele
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSelectionKeyboardMultiple(t *testing.T) {
+	bot, api := newTestBot(t)
+	var done []int
+	sk, err := NewSelectionKeyboard(&SelectionOptions{
+		ID:       "int",
+		Options:  []string{"Go", "Rust", "Zig"},
+		Multiple: true,
+		Min:      1,
+		Max:      2,
+		OnDone:   func(c *Context, selected []int) error { done = selected; return nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	keyboard, _ := sk.Keyboard([]int{2})
+	rows := keyboard.InlineKeyboard
+	if rows[0][0].Text != "⬜ Go" || rows[2][0].Text != "✅ Zig" || rows[3][0].Text != "Done" {
+		t.Errorf("Unexpected keyboard %v", rows)
+	}
+
+	router := NewRouter()
+	sk.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	_ = router.HandleUpdate(ctx, menuCallback(rows[0][0].CallbackData))
+	toggled := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if toggled[0][0].Text != "✅ Go" || toggled[2][0].Text != "✅ Zig" {
+		t.Fatalf("Expected Go and Zig to be selected, got %v", toggled)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback(toggled[1][0].CallbackData))
+	answers := api.callsTo("answerCallbackQuery")
+	if last := answers[len(answers)-1].Params; last["text"] != "Select at most 2" {
+		t.Errorf("Expected the maximum to be enforced, got %v", last)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback(toggled[3][0].CallbackData))
+	if fmt.Sprint(done) != "[0 2]" {
+		t.Errorf("Expected selection [0 2], got %v", done)
+	}
+
+	empty, _ := sk.Keyboard(nil)
+	done = nil
+	_ = router.HandleUpdate(ctx, menuCallback(empty.InlineKeyboard[3][0].CallbackData))
+	if done != nil {
+		t.Errorf("Expected the minimum to be enforced, got %v", done)
+	}
+}
+
+func TestSelectionKeyboardRadioWithStorage(t *testing.T) {
+	bot, api := newTestBot(t)
+	var done []int
+	sk, _ := NewSelectionKeyboard(&SelectionOptions{
+		ID:      "size",
+		Options: []string{"S", "M", "L"},
+		Storage: NewMemorySessionStorage(),
+		OnDone:  func(c *Context, selected []int) error { done = selected; return nil },
+	})
+
+	keyboard, _ := sk.Keyboard([]int{1})
+	if keyboard.InlineKeyboard[1][0].Text != "🔘 M" || keyboard.InlineKeyboard[0][0].CallbackData != "sel:size:t0" {
+		t.Errorf("Unexpected keyboard %v", keyboard.InlineKeyboard)
+	}
+
+	router := NewRouter()
+	sk.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	update := menuCallback("sel:size:t2")
+	update.CallbackQuery.Message.ReplyMarkup = keyboard
+	_ = router.HandleUpdate(ctx, update)
+	toggled := editedKeyboard(t, api.callsTo("editMessageReplyMarkup")[0])
+	if toggled[1][0].Text != "⚪ M" || toggled[2][0].Text != "🔘 L" {
+		t.Errorf("Expected L to replace M, got %v", toggled)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("sel:size:d"))
+	if fmt.Sprint(done) != "[2]" {
+		t.Errorf("Expected stored selection [2], got %v", done)
+	}
+}
+
+func TestSelectionKeyboardForgedDone(t *testing.T) {
+	bot, api := newTestBot(t)
+	var done []int
+	onDone := func(c *Context, selected []int) error { done = selected; return nil }
+	multiple, _ := NewSelectionKeyboard(&SelectionOptions{ID: "multi", Options: []string{"A", "B", "C"}, Multiple: true, Max: 2, OnDone: onDone})
+	radio, _ := NewSelectionKeyboard(&SelectionOptions{ID: "radio", Options: []string{"A", "B", "C"}, OnDone: onDone})
+
+	router := NewRouter()
+	multiple.Register(router.Group)
+	radio.Register(router.Group)
+	ctx := ContextWithBot(context.Background(), bot)
+
+	// Bit masks selecting all three options and the first two options
+	for _, data := range []string{"sel:multi:d.7", "sel:radio:d.3"} {
+		_ = router.HandleUpdate(ctx, menuCallback(data))
+		if done != nil {
+			t.Errorf("%s: expected the forged selection to be rejected, got %v", data, done)
+		}
+	}
+	answers := api.callsTo("answerCallbackQuery")
+	if len(answers) != 2 || answers[0].Params["text"] != "Select at most 2" || answers[1].Params["text"] != "Select at most 1" {
+		t.Errorf("Unexpected answers %v", answers)
+	}
+
+	_ = router.HandleUpdate(ctx, menuCallback("sel:radio:d.2"))
+	if fmt.Sprint(done) != "[1]" {
+		t.Errorf("Expected selection [1], got %v", done)
+	}
+}
+
+func TestSelectionKeyboardNilOptions(t *testing.T) {
+	if _, err := NewSelectionKeyboard(nil); err == nil {
+		t.Error("Expected an error for a selection keyboard without options")
+	}
+}
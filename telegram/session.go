@@ -0,0 +1,122 @@
+package gotele
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SessionScope selects what a session belongs to
+type SessionScope int
+
+const (
+	SessionPerUser     SessionScope = iota // One session per user, shared across chats
+	SessionPerChat                         // One session per chat, shared by its members
+	SessionPerChatUser                     // One session per user in each chat
+)
+
+// SessionOptions configures a Session
+type SessionOptions struct {
+	Storage SessionStorage // Defaults to a MemorySessionStorage
+	Scope   SessionScope
+	TTL     time.Duration // Sessions expire this long after their last change, 0 to keep them
+}
+
+// Session loads a value of type T for the update's user or chat before the handler runs and
+// saves it afterwards if the handler changed it. Saves use optimistic concurrency: if the
+// session was changed by another update in the meantime, the handler error is ErrSessionConflict
+type Session[T any] struct {
+	options SessionOptions
+}
+
+// NewSession creates a session. Options may be nil
+func NewSession[T any](options *SessionOptions) *Session[T] {
+	s := &Session[T]{}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.Storage == nil {
+		s.options.Storage = NewMemorySessionStorage()
+	}
+	return s
+}
+
+// Get returns the session value of the update being handled. Changes to it are saved after the
+// handler returns. It returns nil outside the middleware and for updates without a user or chat
+func (s *Session[T]) Get(ctx context.Context) *T {
+	value, _ := ctx.Value(s).(*T)
+	return value
+}
+
+// Middleware returns the middleware loading and saving the session
+func (s *Session[T]) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, u *Update) error {
+			key, ok := s.key(u)
+			if !ok {
+				return next.HandleUpdate(ctx, u)
+			}
+
+			record, err := s.options.Storage.LoadSession(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to load session %s: %w", key, err)
+			}
+
+			value := new(T)
+			var loaded []byte
+			var version int64
+			if record != nil {
+				loaded, version = record.Data, record.Version
+				if err := json.Unmarshal(record.Data, value); err != nil {
+					return fmt.Errorf("failed to decode session %s: %w", key, err)
+				}
+			} else if loaded, err = json.Marshal(value); err != nil {
+				return fmt.Errorf("failed to encode session %s: %w", key, err)
+			}
+
+			if err := next.HandleUpdate(context.WithValue(ctx, s, value), u); err != nil {
+				return err
+			}
+
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode session %s: %w", key, err)
+			}
+			if bytes.Equal(data, loaded) {
+				return nil
+			}
+
+			updated := &SessionRecord{Data: data}
+			if s.options.TTL > 0 {
+				updated.ExpiresAt = time.Now().Add(s.options.TTL)
+			}
+			if err := s.options.Storage.SaveSession(ctx, key, updated, version); err != nil {
+				return fmt.Errorf("failed to save session %s: %w", key, err)
+			}
+			return nil
+		})
+	}
+}
+
+// key returns the storage key of the update's session
+func (s *Session[T]) key(u *Update) (string, bool) {
+	chat, user := u.EffectiveChat(), u.EffectiveUser()
+	switch s.options.Scope {
+	case SessionPerChat:
+		if chat != nil {
+			return "chat:" + strconv.FormatInt(chat.ID, 10), true
+		}
+	case SessionPerChatUser:
+		if chat != nil && user != nil {
+			return "chat:" + strconv.FormatInt(chat.ID, 10) + ":user:" + strconv.FormatInt(user.ID, 10), true
+		}
+	default:
+		if user != nil {
+			return "user:" + strconv.FormatInt(user.ID, 10), true
+		}
+	}
+	return "", false
+}
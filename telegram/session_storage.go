@@ -0,0 +1,158 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// SessionRecord is a stored session
+type SessionRecord struct {
+	Data      json.RawMessage `json:"data"`
+	Version   int64           `json:"version"`              // Incremented by every save
+	ExpiresAt time.Time       `json:"expires_at,omitempty"` // Zero for sessions that do not expire
+}
+
+// expired reports whether the record has expired at now
+func (r *SessionRecord) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+// SessionStorage persists sessions
+type SessionStorage interface {
+	// LoadSession returns the stored session, or nil if there is none or it expired
+	LoadSession(ctx context.Context, key string) (*SessionRecord, error)
+	// SaveSession stores the session if the stored version still equals version, where 0
+	// means no session is stored. It fails with ErrSessionConflict otherwise
+	SaveSession(ctx context.Context, key string, record *SessionRecord, version int64) error
+}
+
+// MemorySessionStorage keeps sessions in memory
+type MemorySessionStorage struct {
+	mu       sync.Mutex
+	sessions map[string]SessionRecord
+}
+
+// NewMemorySessionStorage creates an empty in-memory session storage
+func NewMemorySessionStorage() *MemorySessionStorage {
+	return &MemorySessionStorage{sessions: map[string]SessionRecord{}}
+}
+
+// LoadSession implements SessionStorage
+func (s *MemorySessionStorage) LoadSession(ctx context.Context, key string) (*SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.sessions[key]
+	if !ok {
+		return nil, nil
+	}
+	if record.expired(time.Now()) {
+		delete(s.sessions, key)
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// SaveSession implements SessionStorage
+func (s *MemorySessionStorage) SaveSession(ctx context.Context, key string, record *SessionRecord, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := checkSessionVersion(s.sessions, key, version); err != nil {
+		return err
+	}
+	saved := *record
+	saved.Version = version + 1
+	s.sessions[key] = saved
+	return nil
+}
+
+// FileSessionStorage keeps all sessions in one JSON file, replaced atomically on every save.
+// Expired sessions are dropped from the file when it is next written
+type FileSessionStorage struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSessionStorage creates a session storage backed by the file at path
+func NewFileSessionStorage(path string) *FileSessionStorage {
+	return &FileSessionStorage{path: path}
+}
+
+// LoadSession implements SessionStorage
+func (s *FileSessionStorage) LoadSession(ctx context.Context, key string) (*SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	record, ok := sessions[key]
+	if !ok || record.expired(time.Now()) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// SaveSession implements SessionStorage
+func (s *FileSessionStorage) SaveSession(ctx context.Context, key string, record *SessionRecord, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := checkSessionVersion(sessions, key, version); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for k, stored := range sessions {
+		if stored.expired(now) {
+			delete(sessions, k)
+		}
+	}
+	saved := *record
+	saved.Version = version + 1
+	sessions[key] = saved
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// load reads the sessions file. A missing file holds no sessions
+func (s *FileSessionStorage) load() (map[string]SessionRecord, error) {
+	sessions := map[string]SessionRecord{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse session file: %w", err)
+	}
+	return sessions, nil
+}
+
+// checkSessionVersion fails with ErrSessionConflict unless the live session stored under key
+// has the given version. Expired sessions count as missing
+func checkSessionVersion(sessions map[string]SessionRecord, key string, version int64) error {
+	var current int64
+	if stored, ok := sessions[key]; ok && !stored.expired(time.Now()) {
+		current = stored.Version
+	}
+	if current != version {
+		return ErrSessionConflict
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionStorages(t *testing.T) {
+	storages := map[string]SessionStorage{
+		"memory": NewMemorySessionStorage(),
+		"file":   NewFileSessionStorage(filepath.Join(t.TempDir(), "sessions.json")),
+	}
+	ctx := context.Background()
+
+	for name, storage := range storages {
+		if err := storage.SaveSession(ctx, "user:1", &SessionRecord{Data: []byte(`{"a":1}`)}, 0); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if err := storage.SaveSession(ctx, "user:1", &SessionRecord{Data: []byte(`{"a":2}`)}, 0); !errors.Is(err, ErrSessionConflict) {
+			t.Errorf("%s: expected ErrSessionConflict for a stale version, got %v", name, err)
+		}
+
+		record, err := storage.LoadSession(ctx, "user:1")
+		if err != nil || record == nil || record.Version != 1 || string(record.Data) != `{"a":1}` {
+			t.Errorf("%s: unexpected session %+v, %v", name, record, err)
+		}
+
+		expired := &SessionRecord{Data: []byte(`{}`), ExpiresAt: time.Now().Add(-time.Second)}
+		if err := storage.SaveSession(ctx, "user:2", expired, 0); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if record, _ := storage.LoadSession(ctx, "user:2"); record != nil {
+			t.Errorf("%s: expected expired session to be gone, got %+v", name, record)
+		}
+		if err := storage.SaveSession(ctx, "user:2", &SessionRecord{Data: []byte(`{}`)}, 0); err != nil {
+			t.Errorf("%s: expected expired session to count as missing, got %v", name, err)
+		}
+	}
+}
+
+func TestFileSessionStorageReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	ctx := context.Background()
+
+	if err := NewFileSessionStorage(path).SaveSession(ctx, "chat:5", &SessionRecord{Data: []byte(`"x"`)}, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	record, err := NewFileSessionStorage(path).LoadSession(ctx, "chat:5")
+	if err != nil || record == nil || string(record.Data) != `"x"` {
+		t.Errorf("Unexpected session %+v, %v", record, err)
+	}
+}
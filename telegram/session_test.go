@@ -0,0 +1,97 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type userSettings struct {
+	Language  string `json:"language"`
+	Onboarded bool   `json:"onboarded"`
+}
+
+// countingSessionStorage counts saves made to a MemorySessionStorage
+type countingSessionStorage struct {
+	*MemorySessionStorage
+	saves int
+}
+
+func (s *countingSessionStorage) SaveSession(ctx context.Context, key string, record *SessionRecord, version int64) error {
+	s.saves++
+	return s.MemorySessionStorage.SaveSession(ctx, key, record, version)
+}
+
+func TestSessionLoadAndSave(t *testing.T) {
+	storage := &countingSessionStorage{MemorySessionStorage: NewMemorySessionStorage()}
+	session := NewSession[userSettings](&SessionOptions{Storage: storage})
+
+	router := NewRouter()
+	router.Use(session.Middleware())
+	router.Message(func(ctx context.Context, u *Update) error {
+		settings := session.Get(ctx)
+		if u.Message.Text != "" {
+			settings.Language = u.Message.Text
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	_ = router.HandleUpdate(ctx, userMessage(1, ""))
+	if storage.saves != 0 {
+		t.Errorf("Expected unchanged session not to be saved, got %d saves", storage.saves)
+	}
+
+	_ = router.HandleUpdate(ctx, userMessage(1, "de"))
+	_ = router.HandleUpdate(ctx, userMessage(1, ""))
+	if storage.saves != 1 {
+		t.Errorf("Expected one save, got %d", storage.saves)
+	}
+
+	record, _ := storage.LoadSession(ctx, "user:1")
+	if record == nil || string(record.Data) != `{"language":"de","onboarded":false}` || record.Version != 1 {
+		t.Errorf("Unexpected stored session: %+v", record)
+	}
+}
+
+func TestSessionConflict(t *testing.T) {
+	storage := NewMemorySessionStorage()
+	session := NewSession[userSettings](&SessionOptions{Storage: storage, Scope: SessionPerChat})
+	ctx := context.Background()
+
+	handler := session.Middleware()(HandlerFunc(func(ctx context.Context, u *Update) error {
+		session.Get(ctx).Onboarded = true
+		// Another update saves the session while this one is being handled
+		return storage.SaveSession(ctx, "chat:10", &SessionRecord{Data: []byte(`{}`)}, 0)
+	}))
+
+	if err := handler.HandleUpdate(ctx, userMessage(1, "hi")); !errors.Is(err, ErrSessionConflict) {
+		t.Errorf("Expected ErrSessionConflict, got %v", err)
+	}
+}
+
+func TestSessionKeys(t *testing.T) {
+	update := userMessage(7, "hi")
+	tests := map[SessionScope]string{
+		SessionPerUser:     "user:7",
+		SessionPerChat:     "chat:10",
+		SessionPerChatUser: "chat:10:user:7",
+	}
+	for scope, expected := range tests {
+		session := NewSession[userSettings](&SessionOptions{Scope: scope})
+		if key, ok := session.key(update); !ok || key != expected {
+			t.Errorf("Scope %d: expected key %q, got %q", scope, expected, key)
+		}
+	}
+
+	var got *userSettings
+	session := NewSession[userSettings](nil)
+	handler := session.Middleware()(HandlerFunc(func(ctx context.Context, u *Update) error {
+		got = session.Get(ctx)
+		return nil
+	}))
+	_ = handler.HandleUpdate(context.Background(), &Update{Poll: &Poll{}})
+	if got != nil {
+		t.Error("Expected no session for updates without a user")
+	}
+}
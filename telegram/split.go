@@ -0,0 +1,206 @@
+package gotele
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Length limits of message text and media captions, in UTF-16 code units after entities are parsed
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+)
+
+// styleEntities may be cut at a chunk boundary and reopened in the next chunk without
+// changing what the reader sees
+var styleEntities = map[string]bool{
+	"bold":                  true,
+	"italic":                true,
+	"underline":             true,
+	"strikethrough":         true,
+	"spoiler":               true,
+	"blockquote":            true,
+	"expandable_blockquote": true,
+}
+
+// Split splits the text into chunks of at most limit UTF-16 code units. Cuts prefer paragraph,
+// line and word boundaries outside entities, then inside formatting such as bold, and only
+// then inside code blocks and links. Entities cut in two are reopened in the next chunk.
+// Limits below 2 are raised to 2 so every character fits, and no chunk is whitespace only
+func (f FormattedText) Split(limit int) []FormattedText {
+	limit = max(limit, 2)
+
+	var chunks []FormattedText
+	rest := f
+	for utf16Len(rest.Text) > limit {
+		var head FormattedText
+		head, rest = rest.cut(limit)
+		if head.Text != "" {
+			chunks = append(chunks, head)
+		}
+	}
+	if len(chunks) == 0 || !isBlank(rest.Text) {
+		chunks = append(chunks, rest)
+	}
+	return chunks
+}
+
+// SplitCaption splits the text into a media caption and the chunks of the remaining text,
+// which can follow the media as messages
+func (f FormattedText) SplitCaption() (FormattedText, []FormattedText) {
+	if utf16Len(f.Text) <= MaxCaptionLength {
+		return f, nil
+	}
+	caption, rest := f.cut(MaxCaptionLength)
+	if isBlank(rest.Text) {
+		return caption, nil
+	}
+	return caption, rest.Split(MaxMessageLength)
+}
+
+// runeSpan is an entity converted to rune indices
+type runeSpan struct {
+	entity     MessageEntity
+	start, end int
+}
+
+// cut splits off the first chunk of at most limit UTF-16 code units. The text must be longer.
+// The chunk is empty when the text starts with more whitespace than fits
+func (f FormattedText) cut(limit int) (FormattedText, FormattedText) {
+	runes := []rune(f.Text)
+	units := make([]int, len(runes)+1)
+	for i, r := range runes {
+		units[i+1] = units[i] + utf16Len(string(r))
+	}
+	fit := sort.Search(len(runes)+1, func(i int) bool { return units[i] > limit }) - 1
+	if fit < 1 {
+		fit = 1
+	}
+
+	spans := make([]runeSpan, 0, len(f.Entities))
+	for _, entity := range f.Entities {
+		start := sort.SearchInts(units, entity.Offset)
+		end := sort.SearchInts(units, entity.Offset+entity.Length)
+		if start < end {
+			spans = append(spans, runeSpan{entity: entity, start: start, end: end})
+		}
+	}
+
+	end, next := cutPoint(runes, spans, fit)
+	head := FormattedText{Text: string(runes[:end])}
+	tail := FormattedText{Text: string(runes[next:])}
+	for _, span := range spans {
+		if span.start < end {
+			entity := span.entity
+			entity.Offset = units[span.start]
+			entity.Length = units[min(span.end, end)] - units[span.start]
+			head.Entities = append(head.Entities, entity)
+		}
+		if span.end > next {
+			start := max(span.start, next)
+			entity := span.entity
+			entity.Offset = units[start] - units[next]
+			entity.Length = units[span.end] - units[start]
+			tail.Entities = append(tail.Entities, entity)
+		}
+	}
+	return head, tail
+}
+
+// cutPoint chooses where the first chunk ends and where the next one starts, skipping the
+// separator between them. Cuts that would leave only whitespace in the chunk are skipped
+func cutPoint(runes []rune, spans []runeSpan, fit int) (int, int) {
+	isParagraph := func(i int) bool { return runes[i] == '\n' && i+1 < len(runes) && runes[i+1] == '\n' }
+	isLine := func(i int) bool { return runes[i] == '\n' }
+	isWord := func(i int) bool { return unicode.IsSpace(runes[i]) }
+
+	first := 0
+	for first < len(runes) && isWord(first) {
+		first++
+	}
+	if first >= fit {
+		return 0, first
+	}
+
+	phases := []func(entityType string) bool{
+		func(string) bool { return false },
+		func(entityType string) bool { return styleEntities[entityType] },
+		func(string) bool { return true },
+	}
+	for _, allowed := range phases {
+		for _, boundary := range []func(int) bool{isParagraph, isLine, isWord} {
+			for i := min(fit, len(runes)-1); i > first; i-- {
+				if !boundary(i) || insideEntity(spans, i, allowed) {
+					continue
+				}
+				next := i
+				for next < len(runes) && (runes[next] == '\n' || isWord(next) && !isLine(i)) {
+					next++
+				}
+				return i, next
+			}
+		}
+	}
+	return fit, fit
+}
+
+// insideEntity reports whether position i falls inside an entity that may not be cut
+func insideEntity(spans []runeSpan, i int, allowed func(string) bool) bool {
+	for _, span := range spans {
+		if span.start < i && i < span.end && !allowed(span.entity.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlank reports whether text holds nothing but whitespace
+func isBlank(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
+
+// SendLongMessage sends text of any length, split into as many messages as needed, and returns
+// the sent messages in order
+func (b *Bot) SendLongMessage(chatID int64, text string, options *SendMessageOptions) ([]*Message, error) {
+	chunks := (FormattedText{Text: text}).Split(MaxMessageLength)
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout*time.Duration(len(chunks)))
+	defer cancel()
+	return b.SendLongMessageWithContext(ctx, chatID, text, options)
+}
+
+// SendLongMessageWithContext sends text of any length with context support. The text is split
+// by FormattedText.Split together with options.Entities; every chunk replies to
+// options.ReplyToMessageID and only the last one carries options.ReplyMarkup. A parse mode
+// cannot be split and fails with ErrSplitParseMode unless the text fits in one message
+func (b *Bot) SendLongMessageWithContext(ctx context.Context, chatID int64, text string, options *SendMessageOptions) ([]*Message, error) {
+	var entities []MessageEntity
+	if options != nil {
+		entities = options.Entities
+	}
+	chunks := (FormattedText{Text: text, Entities: entities}).Split(MaxMessageLength)
+	if len(chunks) > 1 && options != nil && options.ParseMode != "" {
+		return nil, ErrSplitParseMode
+	}
+
+	messages := make([]*Message, 0, len(chunks))
+	for i, chunk := range chunks {
+		var chunkOptions SendMessageOptions
+		if options != nil {
+			chunkOptions = *options
+		}
+		chunkOptions.Entities = chunk.Entities
+		if i < len(chunks)-1 {
+			chunkOptions.ReplyMarkup = nil
+		}
+
+		message, err := b.sendMessage(ctx, newSendMessageRequest(chatID, chunk.Text, &chunkOptions))
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
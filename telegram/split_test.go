@@ -0,0 +1,166 @@
+package gotele
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitBoundaries(t *testing.T) {
+	text := "first paragraph\n\nsecond line\nthird line words"
+	tests := []struct {
+		limit    int
+		expected []string
+	}{
+		{100, []string{text}},
+		{30, []string{"first paragraph", "second line\nthird line words"}},
+		{14, []string{"first", "paragraph", "second line", "third line", "words"}},
+		{4, []string{"firs", "t", "para", "grap", "h", "seco", "nd", "line", "thir", "d", "line", "word", "s"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, chunk := range (FormattedText{Text: text}).Split(test.limit) {
+			got = append(got, chunk.Text)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Split(%d): expected %q, got %q", test.limit, test.expected, got)
+		}
+	}
+}
+
+func TestSplitKeepsIndentation(t *testing.T) {
+	chunks := (FormattedText{Text: "a b\n  c"}).Split(4)
+	if len(chunks) != 2 || chunks[0].Text != "a b" || chunks[1].Text != "  c" {
+		t.Errorf("Expected the line break to be the cut, got %+v", chunks)
+	}
+}
+
+func TestSplitSmallLimits(t *testing.T) {
+	tests := []struct {
+		text     string
+		limit    int
+		expected []string
+	}{
+		{"", 0, []string{""}},
+		{"a", 0, []string{"a"}},
+		{"😀a", 0, []string{"😀", "a"}},
+		{"😀😀", 1, []string{"😀", "😀"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, chunk := range (FormattedText{Text: test.text}).Split(test.limit) {
+			got = append(got, chunk.Text)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Split(%d) of %q: expected %q, got %q", test.limit, test.text, test.expected, got)
+		}
+	}
+}
+
+func TestSplitSkipsBlankChunks(t *testing.T) {
+	tests := map[string][]string{
+		"     abcdef":      {"abc", "def"},
+		"ab\n   \n   \ncd": {"ab", "cd"},
+		"abc    ":          {"abc"},
+	}
+	for text, expected := range tests {
+		var got []string
+		for _, chunk := range (FormattedText{Text: text}).Split(3) {
+			got = append(got, chunk.Text)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Split(3) of %q: expected %q, got %q", text, expected, got)
+		}
+	}
+}
+
+func TestSplitReopensEntities(t *testing.T) {
+	formatted := Format(Bold("😀 one two three"), " end")
+	chunks := formatted.Split(9)
+
+	expected := []FormattedText{
+		{Text: "😀 one", Entities: []MessageEntity{{Type: "bold", Offset: 0, Length: 6}}},
+		{Text: "two three", Entities: []MessageEntity{{Type: "bold", Offset: 0, Length: 9}}},
+		{Text: "end"},
+	}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("Unexpected chunks: %+v", chunks)
+	}
+}
+
+func TestSplitAvoidsCuttingEntities(t *testing.T) {
+	formatted := Format("intro text\n", Pre("line one\nline two", "go"), " ", Link("a b c", "https://e.com"))
+
+	chunks := formatted.Split(20)
+	if len(chunks) != 3 || chunks[0].Text != "intro text" || chunks[1].Text != "line one\nline two" || chunks[2].Text != "a b c" {
+		t.Fatalf("Expected the pre block and the link to stay whole, got %+v", chunks)
+	}
+	if chunks[1].Entities[0].Type != "pre" || chunks[1].Entities[0].Length != 17 || chunks[1].Entities[0].Language != "go" {
+		t.Errorf("Unexpected pre entity: %+v", chunks[1].Entities)
+	}
+
+	// A pre block longer than the limit is cut at a line break
+	chunks = Format(Pre("line one\nline two", "")).Split(10)
+	if len(chunks) != 2 || chunks[1].Text != "line two" || chunks[1].Entities[0].Type != "pre" {
+		t.Errorf("Expected the pre block to be reopened, got %+v", chunks)
+	}
+}
+
+func TestSplitCaption(t *testing.T) {
+	text := strings.Repeat("word ", 300)
+	caption, rest := (FormattedText{Text: text}).SplitCaption()
+	if utf16Len(caption.Text) > MaxCaptionLength || len(rest) != 1 {
+		t.Fatalf("Unexpected caption of %d units and %d chunks", utf16Len(caption.Text), len(rest))
+	}
+	if caption.Text+" "+rest[0].Text != text {
+		t.Error("Expected no text to be lost")
+	}
+
+	short, rest := (FormattedText{Text: "short"}).SplitCaption()
+	if short.Text != "short" || rest != nil {
+		t.Errorf("Expected a short caption to stay whole, got %q and %v", short.Text, rest)
+	}
+}
+
+func TestSendLongMessage(t *testing.T) {
+	bot, api := newTestBot(t)
+	sent := 0
+	api.handle("sendMessage", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		sent++
+		return Message{MessageID: 100 + sent, Text: params["text"].(string)}, nil
+	})
+
+	text := strings.Repeat("a", MaxMessageLength) + "\n\n" + "tail"
+	formatted := Format(Bold(text))
+	keyboard := InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "ok", CallbackData: "ok"}}}}
+	messages, err := bot.SendLongMessage(1, formatted.Text, &SendMessageOptions{
+		Entities:         formatted.Entities,
+		ReplyToMessageID: 7,
+		ReplyMarkup:      keyboard,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(messages) != 2 || messages[0].MessageID != 101 || messages[1].Text != "tail" {
+		t.Fatalf("Unexpected messages: %+v", messages)
+	}
+
+	calls := api.callsTo("sendMessage")
+	for i, call := range calls {
+		if call.Params["reply_to_message_id"] != float64(7) {
+			t.Errorf("Chunk %d: expected a reply to message 7, got %v", i, call.Params)
+		}
+		if _, ok := call.Params["entities"]; !ok {
+			t.Errorf("Chunk %d: expected the bold entity to be reopened", i)
+		}
+		if _, ok := call.Params["reply_markup"]; ok != (i == len(calls)-1) {
+			t.Errorf("Chunk %d: expected the keyboard only on the last chunk", i)
+		}
+	}
+
+	_, err = bot.SendLongMessage(1, text, &SendMessageOptions{ParseMode: ParseModeHTML})
+	if !errors.Is(err, ErrSplitParseMode) {
+		t.Errorf("Expected ErrSplitParseMode, got %v", err)
+	}
+}
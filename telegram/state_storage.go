@@ -0,0 +1,158 @@
+package gotele
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StateKey identifies a conversation: one user in one chat
+type StateKey struct {
+	ChatID int64
+	UserID int64
+}
+
+// String returns the key as "chat:user"
+func (k StateKey) String() string {
+	return strconv.FormatInt(k.ChatID, 10) + ":" + strconv.FormatInt(k.UserID, 10)
+}
+
+// ConversationState is the stored position of a user in a conversation
+type ConversationState struct {
+	State     string          `json:"state"`
+	Data      json.RawMessage `json:"data,omitempty"` // JSON encoded data, see ConversationSession.Data
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// StateStorage persists conversation states
+type StateStorage interface {
+	// GetState returns the stored state, or nil if the key has none
+	GetState(ctx context.Context, key StateKey) (*ConversationState, error)
+	// SetState stores the state for the key
+	SetState(ctx context.Context, key StateKey, state *ConversationState) error
+	// DeleteState removes the state for the key. Deleting a missing state is not an error
+	DeleteState(ctx context.Context, key StateKey) error
+}
+
+// MemoryStateStorage keeps conversation states in memory
+type MemoryStateStorage struct {
+	mu     sync.Mutex
+	states map[StateKey]ConversationState
+}
+
+// NewMemoryStateStorage creates an empty in-memory state storage
+func NewMemoryStateStorage() *MemoryStateStorage {
+	return &MemoryStateStorage{states: map[StateKey]ConversationState{}}
+}
+
+// GetState implements StateStorage
+func (s *MemoryStateStorage) GetState(ctx context.Context, key StateKey) (*ConversationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// SetState implements StateStorage
+func (s *MemoryStateStorage) SetState(ctx context.Context, key StateKey, state *ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = *state
+	return nil
+}
+
+// DeleteState implements StateStorage
+func (s *MemoryStateStorage) DeleteState(ctx context.Context, key StateKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// FileStateStorage keeps all conversation states in one JSON file, replaced atomically on every change
+type FileStateStorage struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStateStorage creates a state storage backed by the file at path
+func NewFileStateStorage(path string) *FileStateStorage {
+	return &FileStateStorage{path: path}
+}
+
+// GetState implements StateStorage
+func (s *FileStateStorage) GetState(ctx context.Context, key StateKey) (*ConversationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	state, ok := states[key.String()]
+	if !ok {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// SetState implements StateStorage
+func (s *FileStateStorage) SetState(ctx context.Context, key StateKey, state *ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.load()
+	if err != nil {
+		return err
+	}
+	states[key.String()] = state
+	return s.save(states)
+}
+
+// DeleteState implements StateStorage
+func (s *FileStateStorage) DeleteState(ctx context.Context, key StateKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := states[key.String()]; !ok {
+		return nil
+	}
+	delete(states, key.String())
+	return s.save(states)
+}
+
+// load reads the states file. A missing file holds no states
+func (s *FileStateStorage) load() (map[string]*ConversationState, error) {
+	states := map[string]*ConversationState{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return states, nil
+}
+
+func (s *FileStateStorage) save(states map[string]*ConversationState) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("failed to marshal states: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
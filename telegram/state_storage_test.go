@@ -0,0 +1,39 @@
+package gotele
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStateStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "states.json")
+	ctx := context.Background()
+	key := StateKey{ChatID: -100, UserID: 5}
+
+	storage := NewFileStateStorage(path)
+	if state, err := storage.GetState(ctx, key); err != nil || state != nil {
+		t.Fatalf("Expected no state, got %+v, %v", state, err)
+	}
+
+	saved := &ConversationState{State: "age", Data: []byte(`{"name":"Ada"}`), UpdatedAt: time.Now().UTC()}
+	if err := storage.SetState(ctx, key, saved); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	state, err := NewFileStateStorage(path).GetState(ctx, key)
+	if err != nil || state == nil {
+		t.Fatalf("Expected stored state, got %+v, %v", state, err)
+	}
+	if state.State != "age" || string(state.Data) != `{"name":"Ada"}` || !state.UpdatedAt.Equal(saved.UpdatedAt) {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	if err := storage.DeleteState(ctx, key); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if state, _ := storage.GetState(ctx, key); state != nil {
+		t.Errorf("Expected state to be deleted, got %+v", state)
+	}
+}
@@ -0,0 +1,219 @@
+package gotele
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// escapeFunc is the template function appended to every action to escape its output
+const escapeFunc = "gotele_escape"
+
+// Markup is text already formatted for the template's parse mode, which templates output as is
+type Markup string
+
+// TemplateOptions represents options for loading message templates
+type TemplateOptions struct {
+	ParseMode string           // ParseModeHTML (default) or ParseModeMarkdownV2
+	Patterns  []string         // Glob patterns of the template files, "*.tmpl" by default
+	Funcs     template.FuncMap // Additional template functions
+}
+
+// Templates are text/template message templates whose interpolated values are escaped for
+// their parse mode. Templates are named after their file and may define more with {{define}}
+type Templates struct {
+	parseMode string
+	template  *template.Template
+}
+
+// LoadTemplates parses message templates from fsys. Besides the text/template builtins they
+// can use bold, italic, underline, strike, spoiler, code, pre, link, mention, raw, date and plural
+func LoadTemplates(fsys fs.FS, options *TemplateOptions) (*Templates, error) {
+	if options == nil {
+		options = &TemplateOptions{}
+	}
+	parseMode := options.ParseMode
+	if parseMode == "" {
+		parseMode = ParseModeHTML
+	}
+	if parseMode != ParseModeHTML && parseMode != ParseModeMarkdownV2 {
+		return nil, fmt.Errorf("unsupported template parse mode %q", parseMode)
+	}
+	patterns := options.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"*.tmpl"}
+	}
+
+	t := &Templates{parseMode: parseMode}
+	root := template.New("").Funcs(templateFuncs).Funcs(options.Funcs).Funcs(template.FuncMap{escapeFunc: t.escape})
+	parsed, err := root.ParseFS(fsys, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+	for _, tmpl := range parsed.Templates() {
+		if tmpl.Tree != nil {
+			escapeActions(tmpl.Tree, tmpl.Tree.Root)
+		}
+	}
+	t.template = parsed
+	return t, nil
+}
+
+// ParseMode returns the parse mode the templates are written in
+func (t *Templates) ParseMode() string {
+	return t.parseMode
+}
+
+// Render executes the named template
+func (t *Templates) Render(name string, data interface{}) (string, error) {
+	var out strings.Builder
+	if err := t.template.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Message executes the named template and returns the text with a copy of options that sets
+// the parse mode, ready for SendMessageAdvanced. Options may be nil
+func (t *Templates) Message(name string, data interface{}, options *SendMessageOptions) (string, *SendMessageOptions, error) {
+	text, err := t.Render(name, data)
+	if err != nil {
+		return "", nil, err
+	}
+	var messageOptions SendMessageOptions
+	if options != nil {
+		messageOptions = *options
+	}
+	messageOptions.ParseMode = t.parseMode
+	messageOptions.Entities = nil
+	return text, &messageOptions, nil
+}
+
+// escape renders fragments for the parse mode, passes Markup through and escapes other values
+func (t *Templates) escape(value interface{}) Markup {
+	switch v := value.(type) {
+	case Markup:
+		return v
+	case Fragment:
+		if t.parseMode == ParseModeMarkdownV2 {
+			return Markup(FormatMarkdownV2(v))
+		}
+		return Markup(FormatHTML(v))
+	}
+	text := fmt.Sprint(value)
+	if t.parseMode == ParseModeMarkdownV2 {
+		return Markup(EscapeMarkdownV2(text))
+	}
+	return Markup(EscapeHTML(text))
+}
+
+// escapeActions appends the escape function to every action that prints a value
+func escapeActions(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		identifier := parse.NewIdentifier(escapeFunc).SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{identifier}})
+	case *parse.IfNode:
+		escapeActions(tree, n.List)
+		escapeActions(tree, n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(tree, n.List)
+		escapeActions(tree, n.ElseList)
+	case *parse.WithNode:
+		escapeActions(tree, n.List)
+		escapeActions(tree, n.ElseList)
+	}
+}
+
+// templateFuncs are the formatting helpers available to every message template
+var templateFuncs = template.FuncMap{
+	"bold":      Bold,
+	"italic":    Italic,
+	"underline": Underline,
+	"strike":    Strikethrough,
+	"spoiler":   Spoiler,
+	"code":      func(v interface{}) Fragment { return Code(fmt.Sprint(v)) },
+	"pre":       Pre,
+	"link":      func(text interface{}, url string) Fragment { return Link(fmt.Sprint(text), url) },
+	"mention":   templateMention,
+	"raw":       func(s string) Markup { return Markup(s) },
+	"date":      templateDate,
+	"plural":    templatePlural,
+}
+
+// templateMention mentions a user by name: {{mention .From}} or {{mention "text" .UserID}}
+func templateMention(args ...interface{}) (Fragment, error) {
+	switch len(args) {
+	case 1:
+		switch user := args[0].(type) {
+		case *User:
+			if user != nil {
+				return Mention(strings.TrimSpace(user.FirstName+" "+user.LastName), user.ID), nil
+			}
+		case User:
+			return Mention(strings.TrimSpace(user.FirstName+" "+user.LastName), user.ID), nil
+		}
+	case 2:
+		if id, ok := toInt64(args[1]); ok {
+			return Mention(fmt.Sprint(args[0]), id), nil
+		}
+	}
+	return Fragment{}, fmt.Errorf("mention expects a user or a text and a user ID, got %v", args)
+}
+
+// templateDate formats a time with a Go layout, for pipelines such as {{.Created | date "2 Jan"}}.
+// The zero time formats as an empty string
+func templateDate(layout string, value interface{}) (string, error) {
+	switch t := value.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	}
+	return "", fmt.Errorf("date expects a time, got %T", value)
+}
+
+// templatePlural picks the singular form for a count of one and the plural form otherwise:
+// {{.N}} {{plural .N "file" "files"}}
+func templatePlural(count interface{}, singular, plural string) (string, error) {
+	n, ok := toInt64(count)
+	if !ok {
+		return "", fmt.Errorf("plural expects an integer count, got %T", count)
+	}
+	if n == 1 || n == -1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+// toInt64 converts any integer value to int64
+func toInt64(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
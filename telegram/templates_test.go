@@ -0,0 +1,96 @@
+package gotele
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testTemplates = fstest.MapFS{
+	"welcome.tmpl": {Data: []byte(`Hi {{.Name}}! {{bold "Order " .ID}} for {{mention .User}}` +
+		`{{with .Link}} {{link "track" .}}{{end}}{{$n := len .Items}} {{$n}} {{plural $n "item" "items"}}` +
+		` on {{.Date | date "2 Jan 2006"}}{{range .Items}}
+- {{.}}{{end}}{{template "footer" .}}`)},
+	"footer.tmpl": {Data: []byte(`{{define "footer"}}
+{{raw .Footer}}{{end}}`)},
+}
+
+type welcomeData struct {
+	Name   string
+	ID     int
+	User   *User
+	Link   string
+	Items  []string
+	Date   time.Time
+	Footer string
+}
+
+func TestTemplatesHTML(t *testing.T) {
+	templates, err := LoadTemplates(testTemplates, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text, options, err := templates.Message("welcome.tmpl", welcomeData{
+		Name:   "<Ann & Bob>",
+		ID:     7,
+		User:   &User{ID: 42, FirstName: "Ann"},
+		Link:   "https://e.com/?a=1&b=2",
+		Items:  []string{"tea", "<milk>"},
+		Date:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Footer: "<i>thanks</i>",
+	}, &SendMessageOptions{ReplyToMessageID: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `Hi &lt;Ann &amp; Bob&gt;! <b>Order 7</b> for <a href="tg://user?id=42">Ann</a>` +
+		` <a href="https://e.com/?a=1&amp;b=2">track</a> 2 items on 1 Mar 2024
+- tea
+- &lt;milk&gt;
+<i>thanks</i>`
+	if text != expected {
+		t.Errorf("Unexpected text:\n%s\nexpected\n%s", text, expected)
+	}
+	if options.ParseMode != ParseModeHTML || options.ReplyToMessageID != 3 {
+		t.Errorf("Unexpected options: %+v", options)
+	}
+}
+
+func TestTemplatesMarkdownV2(t *testing.T) {
+	templates, err := LoadTemplates(fstest.MapFS{
+		"price.md": {Data: []byte(`*Total:* {{.Total}} {{italic .Note}} {{plural .N "unit" "units"}}`)},
+	}, &TemplateOptions{ParseMode: ParseModeMarkdownV2, Patterns: []string{"*.md"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text, err := templates.Render("price.md", map[string]interface{}{"Total": "5.00!", "Note": "a_b", "N": uint8(1)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != `*Total:* 5\.00\! _a\_b_ unit` {
+		t.Errorf("Unexpected text: %q", text)
+	}
+}
+
+func TestTemplatesErrors(t *testing.T) {
+	if _, err := LoadTemplates(testTemplates, &TemplateOptions{ParseMode: ParseModeMarkdown}); err == nil {
+		t.Error("Expected legacy Markdown to be rejected")
+	}
+	if _, err := LoadTemplates(fstest.MapFS{"bad.tmpl": {Data: []byte("{{.Name")}}, nil); err == nil {
+		t.Error("Expected a parse error")
+	}
+
+	templates, err := LoadTemplates(fstest.MapFS{"m.tmpl": {Data: []byte("{{mention .}}")}}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := templates.Render("m.tmpl", "nobody"); err == nil || !strings.Contains(err.Error(), "mention") {
+		t.Errorf("Expected a mention error, got %v", err)
+	}
+	if _, err := templates.Render("missing.tmpl", nil); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
@@ -2,6 +2,7 @@ package gotele
 
 import (
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -338,6 +339,7 @@ type InlineKeyboardButton struct {
 	CallbackGame                 *CallbackGame                `json:"callback_game,omitempty"`
 	Pay                          bool                         `json:"pay,omitempty"`
 	WebApp                       *WebApp                      `json:"web_app,omitempty"`
+	CopyText                     *CopyTextButton              `json:"copy_text,omitempty"`
 }
 
 // LoginURL represents a parameter of the inline keyboard button used to automatically authorize a user
@@ -360,6 +362,11 @@ type SwitchInlineQueryChosenChat struct {
 // CallbackGame represents a placeholder, currently holds no information
 type CallbackGame struct{}
 
+// CopyTextButton represents an inline button that copies text to the clipboard
+type CopyTextButton struct {
+	Text string `json:"text"`
+}
+
 // WebApp represents a Web App
 type WebApp struct {
 	URL string `json:"url"`
@@ -545,6 +552,24 @@ type Bot struct {
 	BaseURL string
 	Client  *http.Client
 	Timeout time.Duration // Default timeout for requests
+
+	meMu sync.Mutex
+	me   *User // Cached getMe result
+}
+
+// BotCommand represents a command shown in the bot's command menu
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// BotCommandScope represents the users a command list applies to. Type is one of default,
+// all_private_chats, all_group_chats, all_chat_administrators, chat, chat_administrators
+// and chat_member
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id,omitempty"`
+	UserID int64  `json:"user_id,omitempty"`
 }
 
 // WebhookInfo represents information about the current status of a webhook
@@ -579,8 +604,26 @@ type WebhookServer struct {
 	Port           string
 	Path           string
 	Handler        WebhookHandler
+	UpdateHandler  HandlerFunc   // Context-aware handler, takes precedence over Handler
+	HandlerTimeout time.Duration // Per-update handler deadline, 0 for none
 	SecretToken    string
 	AllowedUpdates []string
+
+	// URL is the public webhook URL. When set, the server registers it with
+	// Telegram on startup and verifies the registration with getWebhookInfo
+	URL                string
+	Certificate        InputFile // Public key certificate for self-signed setups
+	IPAddress          string
+	MaxConnections     int
+	DropPendingUpdates bool // Only applied when the webhook URL changes
+	DeleteOnShutdown   bool // Call deleteWebhook when the server stops
+
+	TLSCertFile string // Serve HTTPS when both TLS files are set
+	TLSKeyFile  string
+
+	IPAllowlist      *IPAllowlist           // Restricts callers, e.g. to TelegramIPRanges
+	ClientIPResolver *ClientIPResolver      // Resolves client IPs behind trusted proxies for the allowlist and IPAddress
+	HandlerOptions   *WebhookHandlerOptions // Enables the hardened handler when set
 }
 
 // WebhookUpdate represents an incoming webhook update with metadata
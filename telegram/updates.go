@@ -1 +1,453 @@
-package gotele
\ No newline at end of file
+package gotele
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Update types, as used in allowed_updates
+const (
+	UpdateTypeMessage            = "message"
+	UpdateTypeEditedMessage      = "edited_message"
+	UpdateTypeChannelPost        = "channel_post"
+	UpdateTypeEditedChannelPost  = "edited_channel_post"
+	UpdateTypeInlineQuery        = "inline_query"
+	UpdateTypeChosenInlineResult = "chosen_inline_result"
+	UpdateTypeCallbackQuery      = "callback_query"
+	UpdateTypeShippingQuery      = "shipping_query"
+	UpdateTypePreCheckoutQuery   = "pre_checkout_query"
+	UpdateTypePoll               = "poll"
+	UpdateTypePollAnswer         = "poll_answer"
+	UpdateTypeMyChatMember       = "my_chat_member"
+	UpdateTypeChatMember         = "chat_member"
+	UpdateTypeChatJoinRequest    = "chat_join_request"
+	UpdateTypeUnknown            = "unknown"
+)
+
+// Type returns the kind of the update, or UpdateTypeUnknown if it carries none of the known fields
+func (u *Update) Type() string {
+	switch {
+	case u.Message != nil:
+		return UpdateTypeMessage
+	case u.EditedMessage != nil:
+		return UpdateTypeEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateTypeChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateTypeEditedChannelPost
+	case u.InlineQuery != nil:
+		return UpdateTypeInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateTypeChosenInlineResult
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	case u.ShippingQuery != nil:
+		return UpdateTypeShippingQuery
+	case u.PreCheckoutQuery != nil:
+		return UpdateTypePreCheckoutQuery
+	case u.Poll != nil:
+		return UpdateTypePoll
+	case u.PollAnswer != nil:
+		return UpdateTypePollAnswer
+	case u.MyChatMember != nil:
+		return UpdateTypeMyChatMember
+	case u.ChatMember != nil:
+		return UpdateTypeChatMember
+	case u.ChatJoinRequest != nil:
+		return UpdateTypeChatJoinRequest
+	default:
+		return UpdateTypeUnknown
+	}
+}
+
+// EffectiveMessage returns the message carried by the update: a new or edited message or
+// channel post, or the message a callback query's button belongs to
+func (u *Update) EffectiveMessage() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	default:
+		return nil
+	}
+}
+
+// EffectiveChat returns the chat the update belongs to, if any
+func (u *Update) EffectiveChat() *Chat {
+	if message := u.EffectiveMessage(); message != nil {
+		return &message.Chat
+	}
+	return nil
+}
+
+// EffectiveUser returns the user that caused the update, if known
+func (u *Update) EffectiveUser() *User {
+	switch {
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	}
+	if message := u.EffectiveMessage(); message != nil {
+		return message.From
+	}
+	return nil
+}
+
+// Handler handles updates
+type Handler interface {
+	HandleUpdate(ctx context.Context, u *Update) error
+}
+
+// HandlerFunc handles an update. The context is cancelled when the update's delivery ends
+// or its handler deadline passes
+type HandlerFunc func(ctx context.Context, u *Update) error
+
+// HandleUpdate implements Handler
+func (f HandlerFunc) HandleUpdate(ctx context.Context, u *Update) error {
+	return f(ctx, u)
+}
+
+// AdaptWebhookHandler adapts a WebhookHandler to a HandlerFunc, ignoring the context
+func AdaptWebhookHandler(handler WebhookHandler) HandlerFunc {
+	return func(ctx context.Context, u *Update) error {
+		return handler(u)
+	}
+}
+
+type webhookUpdateKey struct{}
+
+// ContextWithWebhookUpdate returns a context carrying the metadata of a webhook delivery
+func ContextWithWebhookUpdate(ctx context.Context, webhookUpdate *WebhookUpdate) context.Context {
+	return context.WithValue(ctx, webhookUpdateKey{}, webhookUpdate)
+}
+
+// WebhookUpdateFromContext returns the metadata of the webhook delivery that carried the update
+func WebhookUpdateFromContext(ctx context.Context) (*WebhookUpdate, bool) {
+	webhookUpdate, ok := ctx.Value(webhookUpdateKey{}).(*WebhookUpdate)
+	return webhookUpdate, ok
+}
+
+type botKey struct{}
+
+// ContextWithBot returns a context carrying the bot that received the update
+func ContextWithBot(ctx context.Context, bot *Bot) context.Context {
+	return context.WithValue(ctx, botKey{}, bot)
+}
+
+// BotFromContext returns the bot that received the update. Pollers and webhook handlers
+// add it to the context of every handler call
+func BotFromContext(ctx context.Context) (*Bot, bool) {
+	bot, ok := ctx.Value(botKey{}).(*Bot)
+	return bot, ok
+}
+
+// PollerOptions configures a Poller
+type PollerOptions struct {
+	Offset         int
+	Limit          int
+	Timeout        time.Duration // Long polling timeout, defaults to DefaultPollTimeout
+	AllowedUpdates []string
+
+	// Handler is called for each update in order. When nil, updates are delivered on Updates()
+	Handler        HandlerFunc
+	HandlerTimeout time.Duration // Per-update handler deadline, 0 for none
+
+	MinBackoff time.Duration   // Delay after the first failed request, defaults to 1 second
+	MaxBackoff time.Duration   // Upper bound for the delay, defaults to 1 minute
+	OnError    func(err error) // Called for retried request errors, handler errors and store errors
+
+	// OffsetStore persists the offset after each update has been handled, or received from
+	// Updates() in channel mode. A stored offset takes precedence over Offset on startup
+	OffsetStore OffsetStore
+
+	// PendingPolicy decides what happens to updates that queued up while the bot was down. It is
+	// applied on startup when nothing is stored or the stored offset is older than PendingAfter
+	PendingPolicy PendingPolicy
+	PendingAfter  time.Duration
+	PendingKeep   int // Number of updates kept by KeepLastPending
+}
+
+// PendingPolicy selects how a starting poller treats updates queued during downtime
+type PendingPolicy int
+
+const (
+	// ProcessPending handles every queued update
+	ProcessPending PendingPolicy = iota
+	// DropPending skips all queued updates
+	DropPending
+	// KeepLastPending handles only the most recent PendingKeep queued updates
+	KeepLastPending
+)
+
+// Poller receives updates with long polling. It tracks the offset, backs off on network
+// errors and server failures, and confirms the last offset when it stops
+type Poller struct {
+	bot     *Bot
+	options PollerOptions
+	updates chan Update
+
+	mu              sync.Mutex
+	offset          int
+	confirmedOffset int
+}
+
+// NewPoller creates a poller for the bot
+func (b *Bot) NewPoller(options *PollerOptions) *Poller {
+	p := &Poller{bot: b}
+	if options != nil {
+		p.options = *options
+	}
+	if p.options.Timeout <= 0 {
+		p.options.Timeout = DefaultPollTimeout
+	}
+	if p.options.MinBackoff <= 0 {
+		p.options.MinBackoff = time.Second
+	}
+	if p.options.MaxBackoff < p.options.MinBackoff {
+		p.options.MaxBackoff = time.Minute
+		if p.options.MaxBackoff < p.options.MinBackoff {
+			p.options.MaxBackoff = p.options.MinBackoff
+		}
+	}
+	if p.options.Handler == nil {
+		p.updates = make(chan Update)
+	}
+	p.offset = p.options.Offset
+	p.confirmedOffset = p.options.Offset
+	return p
+}
+
+// Updates returns the channel updates are delivered on when no Handler is set.
+// The channel is closed when Run returns
+func (p *Poller) Updates() <-chan Update {
+	return p.updates
+}
+
+// Offset returns the offset of the next update to fetch
+func (p *Poller) Offset() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.offset
+}
+
+// Run polls for updates until ctx is cancelled or a request fails with a non-retryable error.
+// On cancellation the update being handled is finished, the offset is confirmed with Telegram
+// and Run returns nil
+func (p *Poller) Run(ctx context.Context) error {
+	if p.updates != nil {
+		defer close(p.updates)
+	}
+
+	if err := p.resume(ctx); err != nil {
+		return err
+	}
+
+	var backoff time.Duration
+	for {
+		if ctx.Err() != nil {
+			return p.confirm()
+		}
+
+		offset := p.Offset()
+		updates, err := p.fetch(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return p.confirm()
+			}
+			if !isRetryableError(err) {
+				return err
+			}
+
+			p.reportError(err)
+			backoff = p.nextBackoff(backoff, err)
+			select {
+			case <-ctx.Done():
+				return p.confirm()
+			case <-time.After(backoff):
+			}
+			continue
+		}
+
+		backoff = 0
+		p.setConfirmedOffset(offset)
+
+		for i := range updates {
+			if !p.deliver(ctx, &updates[i]) {
+				return p.confirm()
+			}
+			p.setOffset(updates[i].UpdateID + 1)
+			p.commit(ctx, updates[i].UpdateID+1)
+
+			if ctx.Err() != nil {
+				return p.confirm()
+			}
+		}
+	}
+}
+
+// resume loads the stored offset and applies the pending update policy
+func (p *Poller) resume(ctx context.Context) error {
+	var savedAt time.Time
+	stored := 0
+	if p.options.OffsetStore != nil {
+		offset, at, err := p.options.OffsetStore.LoadOffset(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load offset: %w", err)
+		}
+		if !at.IsZero() {
+			p.setOffset(offset)
+			p.setConfirmedOffset(offset)
+			stored = offset
+			savedAt = at
+		}
+	}
+
+	if p.options.PendingPolicy == ProcessPending {
+		return nil
+	}
+	if !savedAt.IsZero() && time.Since(savedAt) < p.options.PendingAfter {
+		return nil
+	}
+
+	// A negative offset returns updates counted from the end of the queue and forgets older ones
+	keep := 1
+	if p.options.PendingPolicy == KeepLastPending && p.options.PendingKeep > 0 {
+		keep = p.options.PendingKeep
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, p.bot.Timeout)
+	defer cancel()
+
+	updates, err := p.bot.GetUpdatesAdvancedWithContext(requestCtx, &GetUpdatesOptions{
+		Offset:         -keep,
+		Limit:          keep,
+		AllowedUpdates: p.options.AllowedUpdates,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply pending update policy: %w", err)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	next := updates[len(updates)-1].UpdateID + 1
+	if p.options.PendingPolicy == KeepLastPending && p.options.PendingKeep > 0 {
+		// Updates before the stored offset were handled already and must not run again
+		next = max(stored, updates[0].UpdateID)
+	}
+	p.setOffset(next)
+	p.commit(ctx, next)
+	return nil
+}
+
+// commit saves the offset to the offset store, if one is configured
+func (p *Poller) commit(ctx context.Context, offset int) {
+	if p.options.OffsetStore == nil {
+		return
+	}
+	if err := p.options.OffsetStore.SaveOffset(context.WithoutCancel(ctx), offset); err != nil {
+		p.reportError(fmt.Errorf("failed to save offset %d: %w", offset, err))
+	}
+}
+
+// fetch requests the next batch of updates, keeping the HTTP deadline above the poll timeout
+func (p *Poller) fetch(ctx context.Context, offset int) ([]Update, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, p.options.Timeout+p.bot.Timeout)
+	defer cancel()
+
+	return p.bot.GetUpdatesAdvancedWithContext(requestCtx, &GetUpdatesOptions{
+		Offset:         offset,
+		Limit:          p.options.Limit,
+		Timeout:        p.options.Timeout,
+		AllowedUpdates: p.options.AllowedUpdates,
+	})
+}
+
+// deliver passes an update to the handler or the updates channel. It reports false when
+// ctx was cancelled before a channel consumer received the update
+func (p *Poller) deliver(ctx context.Context, update *Update) bool {
+	if p.options.Handler == nil {
+		select {
+		case p.updates <- *update:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Let the handler finish even when polling is being stopped
+	handlerCtx := ContextWithBot(context.WithoutCancel(ctx), p.bot)
+	if p.options.HandlerTimeout > 0 {
+		var cancel context.CancelFunc
+		handlerCtx, cancel = context.WithTimeout(handlerCtx, p.options.HandlerTimeout)
+		defer cancel()
+	}
+
+	if err := p.options.Handler(handlerCtx, update); err != nil {
+		p.reportError(fmt.Errorf("handler failed for update %d: %w", update.UpdateID, err))
+	}
+	return true
+}
+
+// confirm acknowledges handled updates that Telegram has not seen confirmed yet
+func (p *Poller) confirm() error {
+	p.mu.Lock()
+	offset, confirmed := p.offset, p.confirmedOffset
+	p.mu.Unlock()
+
+	if offset == confirmed {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.bot.Timeout)
+	defer cancel()
+
+	if _, err := p.bot.GetUpdatesAdvancedWithContext(ctx, &GetUpdatesOptions{Offset: offset, Limit: 1}); err != nil {
+		return fmt.Errorf("failed to confirm offset %d: %w", offset, err)
+	}
+	p.setConfirmedOffset(offset)
+	return nil
+}
+
+// nextBackoff doubles the previous delay within the configured bounds, honouring retry_after
+func (p *Poller) nextBackoff(previous time.Duration, err error) time.Duration {
+	next := previous * 2
+	if next < p.options.MinBackoff {
+		next = p.options.MinBackoff
+	}
+	if next > p.options.MaxBackoff {
+		next = p.options.MaxBackoff
+	}
+	if wait := retryAfter(err); wait > next {
+		next = wait
+	}
+	return next
+}
+
+func (p *Poller) reportError(err error) {
+	if p.options.OnError != nil {
+		p.options.OnError(err)
+	}
+}
+
+func (p *Poller) setOffset(offset int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offset = offset
+}
+
+func (p *Poller) setConfirmedOffset(offset int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.confirmedOffset = offset
+}
@@ -0,0 +1,344 @@
+package gotele
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdaptWebhookHandler(t *testing.T) {
+	var received *Update
+	handler := AdaptWebhookHandler(func(update *Update) error {
+		received = update
+		return nil
+	})
+
+	update := &Update{UpdateID: 9}
+	if err := handler(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received != update {
+		t.Error("Expected adapted handler to receive the update")
+	}
+}
+
+func TestWebhookUpdateFromContext(t *testing.T) {
+	if _, ok := WebhookUpdateFromContext(context.Background()); ok {
+		t.Error("Expected no metadata in empty context")
+	}
+
+	metadata := &WebhookUpdate{IPAddress: "149.154.167.200"}
+	ctx := ContextWithWebhookUpdate(context.Background(), metadata)
+	got, ok := WebhookUpdateFromContext(ctx)
+	if !ok || got != metadata {
+		t.Errorf("Expected metadata from context, got %v", got)
+	}
+}
+
+func TestGetUpdatesAdvanced(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.on("getUpdates", []Update{{UpdateID: 10}})
+
+	updates, err := bot.GetUpdatesAdvanced(&GetUpdatesOptions{
+		Offset:         10,
+		Limit:          5,
+		Timeout:        time.Second,
+		AllowedUpdates: []string{"message"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 10 {
+		t.Errorf("Unexpected updates: %v", updates)
+	}
+
+	params := api.callsTo("getUpdates")[0].Params
+	if params["offset"] != "10" || params["limit"] != "5" || params["timeout"] != "1" || params["allowed_updates"] != `["message"]` {
+		t.Errorf("Unexpected query parameters: %v", params)
+	}
+}
+
+// serveUpdates makes the fake API return the given updates from the requested offset on
+func serveUpdates(api *fakeAPI, updates ...Update) {
+	api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		offset, _ := strconv.Atoi(params["offset"].(string))
+		pending := []Update{}
+		for _, update := range updates {
+			if update.UpdateID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		return pending, nil
+	})
+}
+
+func TestPollerHandler(t *testing.T) {
+	bot, api := newTestBot(t)
+	serveUpdates(api, Update{UpdateID: 1}, Update{UpdateID: 2}, Update{UpdateID: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled []int
+	poller := bot.NewPoller(&PollerOptions{
+		Handler: func(ctx context.Context, u *Update) error {
+			handled = append(handled, u.UpdateID)
+			if u.UpdateID == 2 {
+				cancel()
+			}
+			if ctx.Err() != nil {
+				t.Error("Expected handler context to survive poller shutdown")
+			}
+			return nil
+		},
+	})
+
+	if err := poller.Run(ctx); err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+
+	if len(handled) != 2 || handled[0] != 1 || handled[1] != 2 {
+		t.Errorf("Expected updates 1 and 2 to be handled, got %v", handled)
+	}
+	if poller.Offset() != 3 {
+		t.Errorf("Expected offset 3, got %d", poller.Offset())
+	}
+
+	calls := api.callsTo("getUpdates")
+	last := calls[len(calls)-1].Params
+	if last["offset"] != "3" || last["timeout"] != "0" {
+		t.Errorf("Expected final call to confirm offset 3, got %v", last)
+	}
+}
+
+func TestPollerChannel(t *testing.T) {
+	bot, api := newTestBot(t)
+	serveUpdates(api, Update{UpdateID: 7}, Update{UpdateID: 8})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	poller := bot.NewPoller(nil)
+
+	done := make(chan error, 1)
+	go func() { done <- poller.Run(ctx) }()
+
+	first := <-poller.Updates()
+	second := <-poller.Updates()
+	if first.UpdateID != 7 || second.UpdateID != 8 {
+		t.Errorf("Expected updates 7 and 8, got %d and %d", first.UpdateID, second.UpdateID)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+	if _, open := <-poller.Updates(); open {
+		t.Error("Expected updates channel to be closed")
+	}
+}
+
+func TestPollerBackoff(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	failures := 2
+	api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		if failures > 0 {
+			failures--
+			return nil, &APIResponse{ErrorCode: 502, Description: "Bad Gateway"}
+		}
+		return []Update{{UpdateID: 1}}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+	poller := bot.NewPoller(&PollerOptions{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+		OnError:    func(err error) { errs = append(errs, err) },
+		Handler: func(ctx context.Context, u *Update) error {
+			cancel()
+			return nil
+		},
+	})
+
+	if err := poller.Run(ctx); err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 retried errors, got %v", errs)
+	}
+
+	if backoff := poller.nextBackoff(4*time.Millisecond, errors.New("network down")); backoff != 5*time.Millisecond {
+		t.Errorf("Expected backoff capped at 5ms, got %v", backoff)
+	}
+	rateLimited := &HTTPError{StatusCode: 429, Body: `{"ok":false,"parameters":{"retry_after":3}}`}
+	if backoff := poller.nextBackoff(0, rateLimited); backoff != 3*time.Second {
+		t.Errorf("Expected retry_after to be honoured, got %v", backoff)
+	}
+}
+
+func TestPollerStopsOnFatalError(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		return nil, &APIResponse{ErrorCode: 401, Description: "Unauthorized"}
+	})
+
+	poller := bot.NewPoller(&PollerOptions{Handler: func(ctx context.Context, u *Update) error { return nil }})
+	err := poller.Run(context.Background())
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 401 {
+		t.Errorf("Expected 401 error, got %v", err)
+	}
+}
+
+func TestPollerOffsetStore(t *testing.T) {
+	bot, api := newTestBot(t)
+	serveUpdates(api, Update{UpdateID: 4}, Update{UpdateID: 5}, Update{UpdateID: 6})
+
+	store := NewMemoryOffsetStore()
+	_ = store.SaveOffset(context.Background(), 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled []int
+	var storedDuringHandler int
+	poller := bot.NewPoller(&PollerOptions{
+		OffsetStore: store,
+		Handler: func(ctx context.Context, u *Update) error {
+			storedDuringHandler, _, _ = store.LoadOffset(ctx)
+			handled = append(handled, u.UpdateID)
+			if u.UpdateID == 6 {
+				cancel()
+			}
+			return nil
+		},
+	})
+
+	if err := poller.Run(ctx); err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+
+	if len(handled) != 2 || handled[0] != 5 {
+		t.Errorf("Expected to resume from stored offset 5, handled %v", handled)
+	}
+	if storedDuringHandler != 6 {
+		t.Errorf("Expected offset to be committed only after the handler, got %d", storedDuringHandler)
+	}
+	if offset, _, _ := store.LoadOffset(context.Background()); offset != 7 {
+		t.Errorf("Expected stored offset 7, got %d", offset)
+	}
+}
+
+func TestPollerPendingPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PendingPolicy
+		keep     int
+		expected []int
+	}{
+		{"process", ProcessPending, 0, []int{1, 2, 3}},
+		{"drop", DropPending, 0, nil},
+		{"keep last", KeepLastPending, 2, []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		bot, api := newTestBot(t)
+		queued := []Update{{UpdateID: 1}, {UpdateID: 2}, {UpdateID: 3}}
+		api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+			offset, _ := strconv.Atoi(params["offset"].(string))
+			if offset < 0 {
+				return queued[len(queued)+offset:], nil
+			}
+			pending := []Update{}
+			for _, update := range queued {
+				if update.UpdateID >= offset {
+					pending = append(pending, update)
+				}
+			}
+			return pending, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		var handled []int
+		poller := bot.NewPoller(&PollerOptions{
+			PendingPolicy: tt.policy,
+			PendingKeep:   tt.keep,
+			OffsetStore:   NewMemoryOffsetStore(),
+			Handler: func(ctx context.Context, u *Update) error {
+				handled = append(handled, u.UpdateID)
+				if u.UpdateID == 3 {
+					cancel()
+				}
+				return nil
+			},
+		})
+
+		if err := poller.Run(ctx); err != nil {
+			t.Fatalf("%s: expected graceful stop, got %v", tt.name, err)
+		}
+		cancel()
+
+		if len(handled) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, handled)
+			continue
+		}
+		for i := range handled {
+			if handled[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, handled)
+			}
+		}
+		if poller.Offset() != 4 {
+			t.Errorf("%s: expected offset 4, got %d", tt.name, poller.Offset())
+		}
+	}
+}
+
+func TestPollerKeepLastPendingHonoursStoredOffset(t *testing.T) {
+	bot, api := newTestBot(t)
+	queued := []Update{{UpdateID: 1}, {UpdateID: 2}, {UpdateID: 3}, {UpdateID: 4}}
+	api.handle("getUpdates", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		offset, _ := strconv.Atoi(params["offset"].(string))
+		if offset < 0 {
+			return queued[len(queued)+offset:], nil
+		}
+		pending := []Update{}
+		for _, update := range queued {
+			if update.UpdateID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		return pending, nil
+	})
+
+	// Updates 1 to 3 were handled before a long downtime; the kept window starts at 2
+	store := NewMemoryOffsetStore()
+	_ = store.SaveOffset(context.Background(), 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var handled []int
+	poller := bot.NewPoller(&PollerOptions{
+		PendingPolicy: KeepLastPending,
+		PendingKeep:   3,
+		OffsetStore:   store,
+		Handler: func(ctx context.Context, u *Update) error {
+			handled = append(handled, u.UpdateID)
+			if u.UpdateID == 4 {
+				cancel()
+			}
+			return nil
+		},
+	})
+
+	if err := poller.Run(ctx); err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+	if len(handled) != 1 || handled[0] != 4 {
+		t.Errorf("Expected only update 4 to be handled, got %v", handled)
+	}
+}
@@ -1,15 +1,21 @@
 package gotele
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -150,6 +156,17 @@ func ValidateWebhookSignature(secretToken, body, signature string) bool {
 
 // WebhookHandlerFunc creates an HTTP handler for webhook updates
 func (b *Bot) WebhookHandlerFunc(secretToken string, handler WebhookHandler) http.HandlerFunc {
+	return b.webhookHandlerFunc(secretToken, AdaptWebhookHandler(handler), 0)
+}
+
+// WebhookUpdateHandlerFunc creates an HTTP handler for webhook updates that passes each update a
+// context carrying its WebhookUpdate metadata. The context is cancelled when the request ends or,
+// if timeout is positive, when the timeout elapses
+func (b *Bot) WebhookUpdateHandlerFunc(secretToken string, handler HandlerFunc, timeout time.Duration) http.HandlerFunc {
+	return b.webhookHandlerFunc(secretToken, handler, timeout)
+}
+
+func (b *Bot) webhookHandlerFunc(secretToken string, handler HandlerFunc, timeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -184,98 +201,362 @@ func (b *Bot) WebhookHandlerFunc(secretToken string, handler WebhookHandler) htt
 			}
 		}
 
-		// Parse update
-		var update Update
-		if err := json.Unmarshal(body, &update); err != nil {
-			http.Error(w, "Failed to parse update", http.StatusBadRequest)
+		b.serveWebhookUpdate(w, r, body, secretToken, handler, timeout, nil)
+	}
+}
+
+// HardenedWebhookHandler creates an HTTP handler for webhook updates that caps the body size,
+// only accepts JSON, sends no CORS headers unless configured and optionally rate limits callers
+func (b *Bot) HardenedWebhookHandler(handler HandlerFunc, options *WebhookHandlerOptions) http.HandlerFunc {
+	guard := newWebhookGuard(options)
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = guard.resolveClientIP(r)
+		body, ok := guard.admit(w, r)
+		if !ok {
+			return
+		}
+		b.serveWebhookUpdate(w, r, body, guard.options.SecretToken, handler, guard.options.HandlerTimeout, guard)
+	}
+}
+
+// serveWebhookUpdate parses an admitted webhook request body and passes the update to the handler
+func (b *Bot) serveWebhookUpdate(w http.ResponseWriter, r *http.Request, body []byte, secretToken string, handler HandlerFunc, timeout time.Duration, guard *webhookGuard) {
+	// Parse update
+	var update Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		if guard != nil {
+			guard.reject(w, r, RejectMalformedUpdate, http.StatusBadRequest)
 			return
 		}
+		http.Error(w, "Failed to parse update", http.StatusBadRequest)
+		return
+	}
+
+	// Create webhook update with metadata
+	ctx := ContextWithWebhookUpdate(r.Context(), &WebhookUpdate{
+		Update:      &update,
+		Timestamp:   time.Now().Unix(),
+		IPAddress:   getClientIP(r),
+		UserAgent:   r.Header.Get("User-Agent"),
+		SecretToken: secretToken,
+	})
+	ctx = ContextWithBot(ctx, b)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Call handler, collecting an inline response if it sets one
+	reply := &webhookReply{updateID: update.UpdateID}
+	err := handler(context.WithValue(ctx, webhookReplyKey{}, reply), &update)
+	response := reply.close()
 
-		// Create webhook update with metadata (for future use)
-		_ = &WebhookUpdate{
-			Update:      &update,
-			Timestamp:   time.Now().Unix(),
-			IPAddress:   getClientIP(r),
-			UserAgent:   r.Header.Get("User-Agent"),
-			SecretToken: secretToken,
+	if err != nil {
+		// The handler was told its response would be delivered, so it is made through the API
+		// before the error response makes Telegram redeliver the update
+		if response != nil {
+			callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.Timeout)
+			_, _ = b.makeRequest(callCtx, "POST", "/"+response.Method, response.Params)
+			cancel()
 		}
+		http.Error(w, "Handler error", http.StatusInternalServerError)
+		return
+	}
 
-		// Call handler
-		if err := handler(&update); err != nil {
-			http.Error(w, "Handler error", http.StatusInternalServerError)
+	if response != nil {
+		body, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
-
-		// Send success response
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		w.Write(body)
+		return
 	}
+
+	// Send success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
 }
 
-// StartWebhookServer starts an HTTP server for webhook updates
-func (b *Bot) StartWebhookServer(config *WebhookServer) error {
-	// Create HTTP handler
-	handler := b.WebhookHandlerFunc(config.SecretToken, config.Handler)
+// InlineResponse is a Bot API method call sent back as the body of a webhook response
+type InlineResponse struct {
+	Method string
+	Params interface{}
+}
 
-	// Create HTTP server
-	server := &http.Server{
-		Addr:    ":" + config.Port,
-		Handler: http.HandlerFunc(handler),
+// MarshalJSON encodes the call as {"method": ..., <params>}
+func (r *InlineResponse) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{}
+	if r.Params != nil {
+		paramsJSON, err := json.Marshal(r.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal params: %w", err)
+		}
+		if err := json.Unmarshal(paramsJSON, &fields); err != nil {
+			return nil, fmt.Errorf("params must encode to a JSON object: %w", err)
+		}
+	}
+	fields["method"] = r.Method
+	return json.Marshal(fields)
+}
+
+type webhookReplyKey struct{}
+
+// webhookReply holds the inline response of an update while its webhook request is open.
+// It travels in the handler context
+type webhookReply struct {
+	updateID int
+	mu       sync.Mutex
+	closed   bool
+	response *InlineResponse
+}
+
+// offer sets the inline response. It is not accepted once the request has been answered,
+// which closed reports, or when a response has already been set
+func (r *webhookReply) offer(response *InlineResponse) (accepted, closed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.response != nil {
+		return false, r.closed
+	}
+	r.response = response
+	return true, false
+}
+
+// close stops accepting responses and returns the one set, if any
+func (r *webhookReply) close() *InlineResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.response
+}
+
+// RespondWebhook answers update with a Bot API method call. While the update's webhook request
+// is still being handled the call is returned in the HTTP response, saving a round trip; this
+// needs the handler context, which carries the open request. Once the request has been answered
+// (for example from a goroutine), an inline response has already been set, or ctx does not
+// belong to the update's request, the method is called through the API instead. If the handler
+// returns an error after responding inline, the call is made through the API before the error
+// is reported to Telegram. Telegram does not report the outcome of inline responses
+func (b *Bot) RespondWebhook(ctx context.Context, update *Update, method string, params interface{}) error {
+	if reply, ok := ctx.Value(webhookReplyKey{}).(*webhookReply); ok && update != nil && update.UpdateID == reply.updateID {
+		accepted, closed := reply.offer(&InlineResponse{Method: method, Params: params})
+		if accepted {
+			return nil
+		}
+		// The request context is cancelled once the request has been answered
+		if closed {
+			callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.Timeout)
+			defer cancel()
+			ctx = callCtx
+		}
+	}
+
+	_, err := b.makeRequest(ctx, "POST", "/"+method, params)
+	return err
+}
+
+// RespondWithMessage answers update by sending a message, inline when possible
+func (b *Bot) RespondWithMessage(ctx context.Context, update *Update, chatID int64, text string, options *SendMessageOptions) error {
+	return b.RespondWebhook(ctx, update, "sendMessage", newSendMessageRequest(chatID, text, options))
+}
+
+// RespondWithCallbackAnswer answers update by answering a callback query, inline when possible
+func (b *Bot) RespondWithCallbackAnswer(ctx context.Context, update *Update, options *AnswerCallbackQueryOptions) error {
+	return b.RespondWebhook(ctx, update, "answerCallbackQuery", answerCallbackQueryParams(options))
+}
+
+// validSecretToken checks the X-Telegram-Bot-Api-Secret-Token header for the hardened handler.
+// Telegram sends the secret token itself; an HMAC signature of the body is accepted as well
+func validSecretToken(secretToken string, body []byte, r *http.Request) bool {
+	header := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if header != "" && subtle.ConstantTimeCompare([]byte(header), []byte(secretToken)) == 1 {
+		return true
 	}
+	return ValidateWebhookSignature(secretToken, string(body), header)
+}
 
-	// Start server
-	return server.ListenAndServe()
+// StartWebhookServer starts an HTTP server for webhook updates
+func (b *Bot) StartWebhookServer(config *WebhookServer) error {
+	return b.RunWebhookServer(context.Background(), config)
 }
 
 // StartWebhookServerTLS starts an HTTPS server for webhook updates
 func (b *Bot) StartWebhookServerTLS(config *WebhookServer, certFile, keyFile string) error {
-	// Create HTTP handler
-	handler := b.WebhookHandlerFunc(config.SecretToken, config.Handler)
+	tlsConfig := *config
+	tlsConfig.TLSCertFile = certFile
+	tlsConfig.TLSKeyFile = keyFile
+	return b.RunWebhookServer(context.Background(), &tlsConfig)
+}
+
+// RunWebhookServer serves webhook updates until ctx is cancelled. When config.URL is set
+// the webhook is registered and verified once the listener is up, and removed again on
+// shutdown if config.DeleteOnShutdown is set
+func (b *Bot) RunWebhookServer(ctx context.Context, config *WebhookServer) error {
+	listener, err := net.Listen("tcp", ":"+config.Port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %s: %w", config.Port, err)
+	}
 
-	// Create HTTP server
 	server := &http.Server{
-		Addr:    ":" + config.Port,
-		Handler: http.HandlerFunc(handler),
+		Handler: b.webhookServerHandler(config),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+			serveErr <- server.ServeTLS(listener, config.TLSCertFile, config.TLSKeyFile)
+		} else {
+			serveErr <- server.Serve(listener)
+		}
+	}()
+
+	if config.URL != "" {
+		if err := b.RegisterWebhookWithContext(ctx, config); err != nil {
+			_ = server.Close()
+			return err
+		}
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down webhook server: %w", err)
+	}
+
+	if config.DeleteOnShutdown {
+		if err := b.DeleteWebhookWithContext(shutdownCtx, false); err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// webhookServerHandler mounts the webhook handler on the configured path
+func (b *Bot) webhookServerHandler(config *WebhookServer) http.Handler {
+	updateHandler := config.UpdateHandler
+	if updateHandler == nil {
+		updateHandler = AdaptWebhookHandler(config.Handler)
+	}
+
+	var handler http.Handler
+	if config.HandlerOptions != nil {
+		options := *config.HandlerOptions
+		options.SecretToken = config.SecretToken
+		if options.HandlerTimeout == 0 {
+			options.HandlerTimeout = config.HandlerTimeout
+		}
+		handler = b.HardenedWebhookHandler(updateHandler, &options)
+	} else {
+		handler = b.webhookHandlerFunc(config.SecretToken, updateHandler, config.HandlerTimeout)
+	}
+	if config.IPAllowlist != nil {
+		handler = config.IPAllowlist.Middleware(handler)
+	}
+	if config.ClientIPResolver != nil {
+		handler = config.ClientIPResolver.Middleware(handler)
+	}
+	if config.Path == "" {
+		return handler
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, handler)
+	return mux
+}
+
+// RegisterWebhook registers the webhook described by config and verifies it
+func (b *Bot) RegisterWebhook(config *WebhookServer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	return b.RegisterWebhookWithContext(ctx, config)
+}
+
+// RegisterWebhookWithContext registers the webhook described by config and verifies it with context support.
+// Registration is idempotent: re-registering the URL that is already active never drops pending updates,
+// so several instances of a rolling deploy can call it safely
+func (b *Bot) RegisterWebhookWithContext(ctx context.Context, config *WebhookServer) error {
+	if config.URL == "" {
+		return fmt.Errorf("webhook URL is required")
+	}
+
+	options := &SetWebhookOptions{
+		URL:                config.URL,
+		Certificate:        config.Certificate,
+		IPAddress:          config.IPAddress,
+		MaxConnections:     config.MaxConnections,
+		AllowedUpdates:     config.AllowedUpdates,
+		DropPendingUpdates: config.DropPendingUpdates,
+		SecretToken:        config.SecretToken,
 	}
 
-	// Start server with TLS
-	return server.ListenAndServeTLS(certFile, keyFile)
+	if options.DropPendingUpdates {
+		current, err := b.GetWebhookInfoWithContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook info: %w", err)
+		}
+		if current.URL == options.URL {
+			options.DropPendingUpdates = false
+		}
+	}
+
+	// Errors Telegram recorded before this registration belong to an earlier deployment
+	registeredAt := time.Now().Unix()
+	if err := b.SetWebhookWithContext(ctx, options); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	return b.verifyWebhook(ctx, config.URL, registeredAt)
+}
+
+// VerifyWebhook checks that the active webhook points at url and reports no delivery errors.
+// RegisterWebhook only considers errors recorded after it registered the webhook
+func (b *Bot) VerifyWebhook(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	return b.VerifyWebhookWithContext(ctx, url)
 }
 
-// ProcessWebhookUpdate processes a webhook update with routing
+// VerifyWebhookWithContext checks that the active webhook points at url and reports no delivery errors with context support
+func (b *Bot) VerifyWebhookWithContext(ctx context.Context, url string) error {
+	return b.verifyWebhook(ctx, url, 0)
+}
+
+// verifyWebhook checks the active webhook, ignoring delivery errors recorded before since (Unix time)
+func (b *Bot) verifyWebhook(ctx context.Context, url string, since int64) error {
+	info, err := b.GetWebhookInfoWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook info: %w", err)
+	}
+
+	recentError := info.LastErrorMessage != "" && info.LastErrorDate >= since
+	if info.URL != url || recentError {
+		return &WebhookRegistrationError{
+			ExpectedURL:      url,
+			ActualURL:        info.URL,
+			LastErrorMessage: info.LastErrorMessage,
+		}
+	}
+
+	return nil
+}
+
+// ProcessWebhookUpdate processes a webhook update with routing by update type.
+// Router supersedes it with filters, priorities and a NotFound handler
 func (b *Bot) ProcessWebhookUpdate(update *Update, handlers map[string]WebhookHandler) error {
 	// Route based on update type
-	var handlerType string
-
-	if update.Message != nil {
-		handlerType = "message"
-	} else if update.EditedMessage != nil {
-		handlerType = "edited_message"
-	} else if update.ChannelPost != nil {
-		handlerType = "channel_post"
-	} else if update.EditedChannelPost != nil {
-		handlerType = "edited_channel_post"
-	} else if update.InlineQuery != nil {
-		handlerType = "inline_query"
-	} else if update.ChosenInlineResult != nil {
-		handlerType = "chosen_inline_result"
-	} else if update.CallbackQuery != nil {
-		handlerType = "callback_query"
-	} else if update.ShippingQuery != nil {
-		handlerType = "shipping_query"
-	} else if update.PreCheckoutQuery != nil {
-		handlerType = "pre_checkout_query"
-	} else if update.Poll != nil {
-		handlerType = "poll"
-	} else if update.PollAnswer != nil {
-		handlerType = "poll_answer"
-	} else if update.MyChatMember != nil {
-		handlerType = "my_chat_member"
-	} else if update.ChatMember != nil {
-		handlerType = "chat_member"
-	} else if update.ChatJoinRequest != nil {
-		handlerType = "chat_join_request"
-	}
+	handlerType := update.Type()
 
 	// Call specific handler if available
 	if handler, exists := handlers[handlerType]; exists {
@@ -291,30 +572,6 @@ func (b *Bot) ProcessWebhookUpdate(update *Update, handlers map[string]WebhookHa
 	return fmt.Errorf("no handler found for update type: %s", handlerType)
 }
 
-// getClientIP extracts the client IP address from the request
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP in the list
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
-		}
-		return strings.TrimSpace(xff)
-	}
-
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return strings.TrimSpace(xri)
-	}
-
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
-	}
-	return ip
-}
-
 // WebhookMiddleware creates middleware for webhook processing
 func WebhookMiddleware(secretToken string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -344,7 +601,7 @@ func WebhookMiddleware(secretToken string, next http.Handler) http.Handler {
 			}
 
 			// Restore body for next handler
-			r.Body = io.NopCloser(strings.NewReader(string(body)))
+			r.Body = io.NopCloser(bytes.NewReader(body))
 
 			signature := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
 			if !ValidateWebhookSignature(secretToken, string(body), signature) {
@@ -358,10 +615,188 @@ func WebhookMiddleware(secretToken string, next http.Handler) http.Handler {
 	})
 }
 
+// HardenedWebhookMiddleware applies the same request checks as HardenedWebhookHandler
+// and passes admitted requests, with their body restored, to next
+func HardenedWebhookMiddleware(options *WebhookHandlerOptions, next http.Handler) http.Handler {
+	guard := newWebhookGuard(options)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = guard.resolveClientIP(r)
+		body, ok := guard.admit(w, r)
+		if !ok {
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RejectReason identifies why a webhook request was rejected
+type RejectReason string
+
+const (
+	RejectMethodNotAllowed     RejectReason = "method_not_allowed"
+	RejectRateLimited          RejectReason = "rate_limited"
+	RejectUnsupportedMediaType RejectReason = "unsupported_media_type"
+	RejectBodyTooLarge         RejectReason = "body_too_large"
+	RejectReadError            RejectReason = "read_error"
+	RejectInvalidSecret        RejectReason = "invalid_secret"
+	RejectMalformedUpdate      RejectReason = "malformed_update"
+)
+
+// DefaultWebhookMaxBodyBytes caps webhook request bodies when no limit is configured
+const DefaultWebhookMaxBodyBytes = 1 << 20
+
+// WebhookHandlerOptions configures HardenedWebhookHandler and HardenedWebhookMiddleware
+type WebhookHandlerOptions struct {
+	SecretToken   string
+	MaxBodyBytes  int64   // Defaults to DefaultWebhookMaxBodyBytes
+	AllowedOrigin string  // CORS headers are only sent when set
+	RateLimit     float64 // Requests per second per client IP, 0 disables rate limiting
+	RateBurst     int     // Defaults to 1 when rate limiting is enabled
+	OnReject      func(r *http.Request, reason RejectReason)
+
+	// ClientIPResolver resolves the client IP behind trusted proxies for rate limiting and
+	// WebhookUpdate.IPAddress. Without it, the IP resolved earlier in the chain or the direct
+	// peer address is used
+	ClientIPResolver *ClientIPResolver
+
+	HandlerTimeout time.Duration // Per-update handler deadline, 0 for none
+}
+
+// webhookGuard applies the hardened request checks
+type webhookGuard struct {
+	options WebhookHandlerOptions
+	limiter *ipRateLimiter
+}
+
+func newWebhookGuard(options *WebhookHandlerOptions) *webhookGuard {
+	guard := &webhookGuard{}
+	if options != nil {
+		guard.options = *options
+	}
+	if guard.options.MaxBodyBytes <= 0 {
+		guard.options.MaxBodyBytes = DefaultWebhookMaxBodyBytes
+	}
+	if guard.options.RateLimit > 0 {
+		guard.limiter = newIPRateLimiter(guard.options.RateLimit, guard.options.RateBurst)
+	}
+	return guard
+}
+
+// admit validates the request and returns its body, writing a rejection response otherwise
+func (g *webhookGuard) admit(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if g.options.AllowedOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", g.options.AllowedOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Telegram-Bot-Api-Secret-Token")
+		w.Header().Set("Vary", "Origin")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return nil, false
+		}
+	}
+
+	if r.Method != "POST" {
+		g.reject(w, r, RejectMethodNotAllowed, http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	if g.limiter != nil && !g.limiter.allow(getClientIP(r)) {
+		g.reject(w, r, RejectRateLimited, http.StatusTooManyRequests)
+		return nil, false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		g.reject(w, r, RejectUnsupportedMediaType, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, g.options.MaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			g.reject(w, r, RejectBodyTooLarge, http.StatusRequestEntityTooLarge)
+		} else {
+			g.reject(w, r, RejectReadError, http.StatusBadRequest)
+		}
+		return nil, false
+	}
+
+	if g.options.SecretToken != "" && !validSecretToken(g.options.SecretToken, body, r) {
+		g.reject(w, r, RejectInvalidSecret, http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return body, true
+}
+
+// resolveClientIP stores the client IP in the request context when a resolver is configured
+func (g *webhookGuard) resolveClientIP(r *http.Request) *http.Request {
+	if g.options.ClientIPResolver == nil {
+		return r
+	}
+	return withClientIP(r, g.options.ClientIPResolver.ClientIP(r))
+}
+
+// reject reports the rejection and writes the matching error response
+func (g *webhookGuard) reject(w http.ResponseWriter, r *http.Request, reason RejectReason, status int) {
+	if g.options.OnReject != nil {
+		g.options.OnReject(r, reason)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// RejectionCounter counts webhook rejections by reason. Its Record method can be used as OnReject
+type RejectionCounter struct {
+	mu     sync.Mutex
+	counts map[RejectReason]int64
+}
+
+// Record counts a rejection
+func (c *RejectionCounter) Record(r *http.Request, reason RejectReason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[RejectReason]int64)
+	}
+	c.counts[reason]++
+}
+
+// Count returns the number of rejections recorded for reason
+func (c *RejectionCounter) Count(reason RejectReason) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[reason]
+}
+
+// Snapshot returns a copy of all rejection counts
+func (c *RejectionCounter) Snapshot() map[RejectReason]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := make(map[RejectReason]int64, len(c.counts))
+	for reason, count := range c.counts {
+		snapshot[reason] = count
+	}
+	return snapshot
+}
+
 // WebhookLogger creates a logging middleware for webhook requests
 func WebhookLogger(next http.Handler) http.Handler {
+	return WebhookLoggerWithResolver(nil, next)
+}
+
+// WebhookLoggerWithResolver creates a logging middleware that logs the client IP resolved by
+// resolver and passes it on to next. A nil resolver logs the IP resolved earlier in the chain,
+// or the direct peer address
+func WebhookLoggerWithResolver(resolver *ClientIPResolver, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		if resolver != nil {
+			r = withClientIP(r, resolver.ClientIP(r))
+		}
 
 		// Create response writer wrapper to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
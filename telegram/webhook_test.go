@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -310,31 +312,23 @@ func TestProcessWebhookUpdate(t *testing.T) {
 }
 
 func TestGetClientIP(t *testing.T) {
-	// Test X-Forwarded-For header
+	// Forwarding headers are ignored without a trusted proxy
 	req := httptest.NewRequest("POST", "/webhook", nil)
+	req.RemoteAddr = "192.168.1.3:12345"
 	req.Header.Set("X-Forwarded-For", "192.168.1.1, 10.0.0.1")
-
-	ip := getClientIP(req)
-	if ip != "192.168.1.1" {
-		t.Errorf("Expected IP '192.168.1.1', got %s", ip)
-	}
-
-	// Test X-Real-IP header
-	req = httptest.NewRequest("POST", "/webhook", nil)
 	req.Header.Set("X-Real-IP", "192.168.1.2")
 
-	ip = getClientIP(req)
-	if ip != "192.168.1.2" {
-		t.Errorf("Expected IP '192.168.1.2', got %s", ip)
+	ip := getClientIP(req)
+	if ip != "192.168.1.3" {
+		t.Errorf("Expected IP '192.168.1.3', got %s", ip)
 	}
 
-	// Test RemoteAddr fallback
-	req = httptest.NewRequest("POST", "/webhook", nil)
-	req.RemoteAddr = "192.168.1.3:12345"
+	// The IP resolved earlier in the chain takes precedence
+	req = withClientIP(req, "149.154.167.200")
 
 	ip = getClientIP(req)
-	if ip != "192.168.1.3" {
-		t.Errorf("Expected IP '192.168.1.3', got %s", ip)
+	if ip != "149.154.167.200" {
+		t.Errorf("Expected IP '149.154.167.200', got %s", ip)
 	}
 }
 
@@ -436,3 +430,470 @@ func TestResponseWriter(t *testing.T) {
 		t.Errorf("Expected %d bytes written, got %d", len(data), n)
 	}
 }
+
+func TestRegisterWebhook(t *testing.T) {
+	bot, api := newTestBot(t)
+	currentURL := ""
+	api.handle("setWebhook", func(params map[string]interface{}) (interface{}, *APIResponse) {
+		currentURL = params["url"].(string)
+		return true, nil
+	})
+	api.handle("getWebhookInfo", func(map[string]interface{}) (interface{}, *APIResponse) {
+		return WebhookInfo{URL: currentURL}, nil
+	})
+
+	config := &WebhookServer{
+		URL:                "https://example.com/webhook",
+		SecretToken:        "secret123",
+		AllowedUpdates:     []string{"message"},
+		MaxConnections:     10,
+		DropPendingUpdates: true,
+	}
+
+	if err := bot.RegisterWebhook(config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	calls := api.callsTo("setWebhook")
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 setWebhook call, got %d", len(calls))
+	}
+	if calls[0].Params["secret_token"] != "secret123" {
+		t.Errorf("Expected secret token to be sent, got %v", calls[0].Params["secret_token"])
+	}
+	if calls[0].Params["max_connections"] != float64(10) {
+		t.Errorf("Expected max_connections 10, got %v", calls[0].Params["max_connections"])
+	}
+	if calls[0].Params["drop_pending_updates"] != true {
+		t.Error("Expected pending updates to be dropped on first registration")
+	}
+
+	// Registering the same URL again must not drop pending updates
+	if err := bot.RegisterWebhook(config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	calls = api.callsTo("setWebhook")
+	if _, ok := calls[1].Params["drop_pending_updates"]; ok {
+		t.Error("Expected re-registration to keep pending updates")
+	}
+}
+
+func TestRegisterWebhookVerification(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	api.on("getWebhookInfo", WebhookInfo{URL: "https://other.example.com/webhook"})
+	err := bot.RegisterWebhook(&WebhookServer{URL: "https://example.com/webhook"})
+	regErr, ok := err.(*WebhookRegistrationError)
+	if !ok {
+		t.Fatalf("Expected WebhookRegistrationError, got %T", err)
+	}
+	if regErr.ActualURL != "https://other.example.com/webhook" {
+		t.Errorf("Expected actual URL to be reported, got %s", regErr.ActualURL)
+	}
+
+	api.on("getWebhookInfo", WebhookInfo{URL: "https://example.com/webhook", LastErrorMessage: "Connection refused", LastErrorDate: time.Now().Unix() + 1})
+	err = bot.RegisterWebhook(&WebhookServer{URL: "https://example.com/webhook"})
+	if regErr, ok := err.(*WebhookRegistrationError); !ok || regErr.LastErrorMessage != "Connection refused" {
+		t.Errorf("Expected registration error with last error message, got %v", err)
+	}
+
+	// An error from before the registration, e.g. during a rolling deploy, is stale
+	stale := WebhookInfo{URL: "https://example.com/webhook", LastErrorMessage: "Connection refused", LastErrorDate: time.Now().Unix() - 600}
+	api.on("getWebhookInfo", stale)
+	if err := bot.RegisterWebhook(&WebhookServer{URL: "https://example.com/webhook"}); err != nil {
+		t.Errorf("Expected a stale delivery error to be ignored, got %v", err)
+	}
+	if err := bot.VerifyWebhook("https://example.com/webhook"); err == nil {
+		t.Error("Expected VerifyWebhook to report any delivery error")
+	}
+
+	if err := bot.RegisterWebhook(&WebhookServer{}); err == nil {
+		t.Error("Expected error for missing URL")
+	}
+}
+
+func TestRunWebhookServerLifecycle(t *testing.T) {
+	bot, api := newTestBot(t)
+	api.on("getWebhookInfo", WebhookInfo{URL: "https://example.com/webhook"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.RunWebhookServer(ctx, &WebhookServer{
+			Port:             "0",
+			Path:             "/webhook",
+			Handler:          func(update *Update) error { return nil },
+			URL:              "https://example.com/webhook",
+			DeleteOnShutdown: true,
+		})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(api.callsTo("getWebhookInfo")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected server to stop after context cancellation")
+	}
+
+	methods := strings.Join(api.methods(), ",")
+	if methods != "setWebhook,getWebhookInfo,deleteWebhook" {
+		t.Errorf("Unexpected API call sequence: %s", methods)
+	}
+}
+
+func TestHardenedWebhookHandler(t *testing.T) {
+	bot := NewBot("test_token")
+	counter := &RejectionCounter{}
+
+	handlerCalled := false
+	handler := bot.HardenedWebhookHandler(func(ctx context.Context, update *Update) error {
+		handlerCalled = true
+		return nil
+	}, &WebhookHandlerOptions{
+		SecretToken:  "secret123",
+		MaxBodyBytes: 64,
+		RateLimit:    1,
+		RateBurst:    2,
+		OnReject:     counter.Record,
+	})
+
+	send := func(method, contentType, secret, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/webhook", strings.NewReader(body))
+		req.RemoteAddr = "149.154.167.200:443"
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Telegram sends the secret token verbatim
+	w := send("POST", "application/json; charset=utf-8", "secret123", `{"update_id":1}`)
+	if w.Code != http.StatusOK || !handlerCalled {
+		t.Errorf("Expected update to be accepted, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no CORS headers by default")
+	}
+
+	w = send("POST", "text/plain", "secret123", `{"update_id":1}`)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", w.Code)
+	}
+
+	// Burst is exhausted for this IP
+	w = send("POST", "application/json", "secret123", `{"update_id":1}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", w.Code)
+	}
+
+	if counter.Count(RejectUnsupportedMediaType) != 1 || counter.Count(RejectRateLimited) != 1 {
+		t.Errorf("Unexpected rejection counts: %v", counter.Snapshot())
+	}
+}
+
+func TestHardenedWebhookHandlerRejections(t *testing.T) {
+	bot := NewBot("test_token")
+	var reasons []RejectReason
+	handler := bot.HardenedWebhookHandler(func(ctx context.Context, update *Update) error { return nil }, &WebhookHandlerOptions{
+		SecretToken:  "secret123",
+		MaxBodyBytes: 32,
+		OnReject:     func(r *http.Request, reason RejectReason) { reasons = append(reasons, reason) },
+	})
+
+	tests := []struct {
+		method string
+		secret string
+		body   string
+		status int
+		reason RejectReason
+	}{
+		{"OPTIONS", "secret123", "", http.StatusMethodNotAllowed, RejectMethodNotAllowed},
+		{"POST", "secret123", `{"update_id":1,"padding":"xxxxxxxxxxxxxxxx"}`, http.StatusRequestEntityTooLarge, RejectBodyTooLarge},
+		{"POST", "wrong", `{"update_id":1}`, http.StatusUnauthorized, RejectInvalidSecret},
+		{"POST", "secret123", `{"update_id":`, http.StatusBadRequest, RejectMalformedUpdate},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", tt.secret)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Case %d: expected status %d, got %d", i, tt.status, w.Code)
+		}
+		if len(reasons) != i+1 || reasons[i] != tt.reason {
+			t.Errorf("Case %d: expected reason %s, got %v", i, tt.reason, reasons)
+		}
+	}
+}
+
+func TestHardenedWebhookMiddlewareCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	middleware := HardenedWebhookMiddleware(&WebhookHandlerOptions{AllowedOrigin: "https://admin.example.com"}, next)
+
+	req := httptest.NewRequest("OPTIONS", "/webhook", nil)
+	w := httptest.NewRecorder()
+	middleware.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for configured preflight, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" {
+		t.Errorf("Expected configured origin, got %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	req = httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	middleware.ServeHTTP(w, req)
+	if w.Body.String() != `{"update_id":1}` {
+		t.Errorf("Expected body to be restored for next handler, got %q", w.Body.String())
+	}
+}
+
+func TestWebhookInlineResponse(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		// A copy of the update still matches the open request
+		copied := *update
+		return bot.RespondWithMessage(ctx, &copied, update.Message.Chat.ID, "pong", &SendMessageOptions{
+			ReplyToMessageID: update.Message.MessageID,
+		})
+	}, 0)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1,"message":{"message_id":7,"chat":{"id":42,"type":"private"},"text":"ping"}}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", w.Header().Get("Content-Type"))
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON body, got %q", w.Body.String())
+	}
+	if body["method"] != "sendMessage" || body["text"] != "pong" || body["chat_id"] != float64(42) || body["reply_to_message_id"] != float64(7) {
+		t.Errorf("Unexpected inline response: %v", body)
+	}
+	if len(api.methods()) != 0 {
+		t.Errorf("Expected no API calls, got %v", api.methods())
+	}
+}
+
+func TestWebhookInlineResponseFallback(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	var pending *Update
+	var pendingCtx context.Context
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		// A second response cannot be inlined and is sent through the API
+		_ = bot.RespondWithCallbackAnswer(ctx, update, &AnswerCallbackQueryOptions{CallbackQueryID: "q1"})
+		pending, pendingCtx = update, ctx
+		return bot.RespondWithCallbackAnswer(ctx, update, &AnswerCallbackQueryOptions{CallbackQueryID: "q1", Text: "again"})
+	}, 0)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1,"callback_query":{"id":"q1"}}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `"method":"answerCallbackQuery"`) {
+		t.Errorf("Expected inline callback answer, got %q", w.Body.String())
+	}
+	if calls := api.callsTo("answerCallbackQuery"); len(calls) != 1 || calls[0].Params["text"] != "again" {
+		t.Errorf("Expected second answer to be sent through the API, got %v", calls)
+	}
+
+	// Responding after the request has been answered falls back to an API call
+	if err := bot.RespondWebhook(pendingCtx, pending, "sendMessage", map[string]interface{}{"chat_id": 1, "text": "late"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls := api.callsTo("sendMessage"); len(calls) != 1 || calls[0].Params["text"] != "late" {
+		t.Errorf("Expected late response to be sent through the API, got %v", calls)
+	}
+}
+
+func TestWebhookInlineResponseFromGoroutine(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	server := httptest.NewServer(bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		go func() {
+			<-release
+			done <- bot.RespondWithMessage(ctx, update, 1, "late", nil)
+		}()
+		return nil
+	}, 10*time.Second))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"update_id":1,"message":{"message_id":1,"chat":{"id":1}}}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	// The request has been answered and its context cancelled by now
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the late response to be sent, got %v", err)
+	}
+	if calls := api.callsTo("sendMessage"); len(calls) != 1 || calls[0].Params["text"] != "late" {
+		t.Errorf("Expected late response to be sent through the API, got %v", calls)
+	}
+}
+
+func TestWebhookInlineResponseHandlerError(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		if err := bot.RespondWithMessage(ctx, update, 42, "pong", nil); err != nil {
+			return err
+		}
+		return errors.New("storage unavailable")
+	}, 0)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1,"message":{"message_id":7,"chat":{"id":42,"type":"private"},"text":"ping"}}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	// The error is reported and the promised response is still delivered
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if calls := api.callsTo("sendMessage"); len(calls) != 1 || calls[0].Params["text"] != "pong" {
+		t.Errorf("Expected the inline response to be sent through the API, got %v", calls)
+	}
+}
+
+func TestWebhookInlineResponseOtherUpdate(t *testing.T) {
+	bot, api := newTestBot(t)
+
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		return bot.RespondWithMessage(ctx, &Update{UpdateID: 99}, 42, "other", nil)
+	}, 0)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Body.String() != "OK" || len(api.callsTo("sendMessage")) != 1 {
+		t.Errorf("Expected a response to another update to go through the API, got %q", w.Body.String())
+	}
+}
+
+func TestWebhookUpdateHandlerFunc(t *testing.T) {
+	bot := NewBot("test_token")
+
+	var metadata *WebhookUpdate
+	var hasDeadline bool
+	var ctxBot *Bot
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		metadata, _ = WebhookUpdateFromContext(ctx)
+		_, hasDeadline = ctx.Deadline()
+		ctxBot, _ = BotFromContext(ctx)
+		return nil
+	}, time.Second)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":5}`))
+	req.RemoteAddr = "149.154.167.200:443"
+	req.Header.Set("User-Agent", "TelegramBot/1.0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if metadata == nil {
+		t.Fatal("Expected webhook metadata in context")
+	}
+	if metadata.Update.UpdateID != 5 || metadata.IPAddress != "149.154.167.200" || metadata.UserAgent != "TelegramBot/1.0" {
+		t.Errorf("Unexpected metadata: %+v", metadata)
+	}
+	if !hasDeadline {
+		t.Error("Expected handler context to carry the per-update timeout")
+	}
+	if ctxBot != bot {
+		t.Error("Expected handler context to carry the bot")
+	}
+}
+
+func TestWebhookUpdateHandlerTimeout(t *testing.T) {
+	bot := NewBot("test_token")
+
+	handler := bot.WebhookUpdateHandlerFunc("", func(ctx context.Context, update *Update) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 10*time.Millisecond)
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":5}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after handler timeout, got %d", w.Code)
+	}
+}
+
+func TestWebhookHandlerFuncRequiresSignature(t *testing.T) {
+	bot := NewBot("test_token")
+	handler := bot.WebhookHandlerFunc("secret123", func(update *Update) error { return nil })
+
+	// Accepting the raw token is limited to the hardened handler
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1}`))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "secret123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
+
+func TestHardenedWebhookHandlerRateLimitsResolvedIP(t *testing.T) {
+	bot := NewBot("test_token")
+	resolver, _ := NewClientIPResolver([]string{"10.0.0.0/8"})
+
+	var addresses []string
+	handler := bot.HardenedWebhookHandler(func(ctx context.Context, update *Update) error {
+		metadata, _ := WebhookUpdateFromContext(ctx)
+		addresses = append(addresses, metadata.IPAddress)
+		return nil
+	}, &WebhookHandlerOptions{RateLimit: 1, RateBurst: 1, ClientIPResolver: resolver})
+
+	send := func(client string) int {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"update_id":1}`))
+		req.RemoteAddr = "10.0.0.2:443"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Clients behind the same proxy have separate buckets
+	if send("198.51.100.1") != http.StatusOK || send("198.51.100.2") != http.StatusOK {
+		t.Error("Expected each client to have its own bucket")
+	}
+	if send("198.51.100.1") != http.StatusTooManyRequests {
+		t.Error("Expected the first client to be rate limited")
+	}
+	if len(addresses) != 2 || addresses[0] != "198.51.100.1" {
+		t.Errorf("Expected IPAddress to be the resolved client IP, got %v", addresses)
+	}
+}